@@ -0,0 +1,128 @@
+package refdir
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// singleCallerFuncs returns the functions and methods of the package referenced from
+// exactly one top-level declaration other than their own, for -adjacency.
+func singleCallerFuncs(pass *analysis.Pass) map[*types.Func]bool {
+	callers := make(map[*types.Func]map[ast.Decl]bool)
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			ast.Inspect(decl, func(n ast.Node) bool {
+				ident, ok := n.(*ast.Ident)
+				if !ok {
+					return true
+				}
+				fn, ok := pass.TypesInfo.Uses[ident].(*types.Func)
+				if !ok || fn.Pkg() != pass.Pkg {
+					return true
+				}
+				fn = fn.Origin()
+				if fd, ok := decl.(*ast.FuncDecl); ok && pass.TypesInfo.Defs[fd.Name] == fn {
+					return true
+				}
+				if callers[fn] == nil {
+					callers[fn] = make(map[ast.Decl]bool)
+				}
+				callers[fn][decl] = true
+				return true
+			})
+		}
+	}
+
+	single := make(map[*types.Func]bool)
+	for fn, decls := range callers {
+		if len(decls) == 1 {
+			single[fn] = true
+		}
+	}
+	return single
+}
+
+// declDistance returns how many top-level declarations of file separate those containing
+// a and b, or 0 if either is outside all declarations.
+func declDistance(file *ast.File, a, b token.Pos) int {
+	ai, bi := -1, -1
+	for i, decl := range file.Decls {
+		if decl.Pos() <= a && a < decl.End() {
+			ai = i
+		}
+		if decl.Pos() <= b && b < decl.End() {
+			bi = i
+		}
+	}
+	if ai < 0 || bi < 0 {
+		return 0
+	}
+	return max(ai-bi, bi-ai)
+}
+
+// nearMiss returns the top-level declaration of file that alone separates the declarations
+// containing ref and def, if it neither uses nor declares anything used by either of them.
+func nearMiss(info *types.Info, file *ast.File, ref, def token.Pos) (ast.Decl, bool) {
+	ri, di := -1, -1
+	for i, decl := range file.Decls {
+		if decl.Pos() <= ref && ref < decl.End() {
+			ri = i
+		}
+		if decl.Pos() <= def && def < decl.End() {
+			di = i
+		}
+	}
+	if ri < 0 || di < 0 || max(ri-di, di-ri) != 2 {
+		return nil, false
+	}
+	between := file.Decls[(ri+di)/2]
+	for _, decl := range []ast.Decl{file.Decls[ri], file.Decls[di]} {
+		if declUses(info, between, decl) || declUses(info, decl, between) {
+			return nil, false
+		}
+	}
+	return between, true
+}
+
+// declLabel names decl for messages, as in "func (*T).Set" or "var a".
+func declLabel(decl ast.Decl) string {
+	switch decl := decl.(type) {
+	case *ast.FuncDecl:
+		return "func " + funcDeclName(decl)
+	case *ast.GenDecl:
+		label := decl.Tok.String()
+		for _, spec := range decl.Specs {
+			switch spec := spec.(type) {
+			case *ast.TypeSpec:
+				return label + " " + spec.Name.Name
+			case *ast.ValueSpec:
+				return label + " " + spec.Names[0].Name
+			}
+		}
+		return label
+	default:
+		return "declaration"
+	}
+}
+
+// declUses reports whether from uses an object declared in to.
+func declUses(info *types.Info, from, to ast.Decl) bool {
+	declared := make(map[types.Object]bool)
+	ast.Inspect(to, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && info.Defs[ident] != nil {
+			declared[info.Defs[ident]] = true
+		}
+		return true
+	})
+	uses := false
+	ast.Inspect(from, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && declared[info.Uses[ident]] {
+			uses = true
+		}
+		return !uses
+	})
+	return uses
+}
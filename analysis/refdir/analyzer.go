@@ -1,49 +1,59 @@
 package refdir
 
 import (
+	"cmp"
 	"errors"
 	"flag"
 	"fmt"
-	"go/ast"
 	"go/token"
-	"go/types"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
 
 	"github.com/ppipada/refdir/analysis/refdir/color"
 
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
-	"golang.org/x/tools/go/ast/inspector"
 )
 
-var Analyzer = &analysis.Analyzer{
-	Name:     "refdir",
-	Doc:      "Report potential reference-to-declaration ordering issues",
-	Run:      run,
-	Flags:    flag.FlagSet{},
-	Requires: []*analysis.Analyzer{inspect.Analyzer},
-}
+const analyzerName = "refdir"
 
-var (
-	verbose  bool
-	colorize bool
-)
+// Analyzer is the default refdir analyzer, configured through its own flags.
+// Embedders that run several configurations should use NewWithOptions instead.
+var Analyzer = NewWithOptions(DefaultOptions())
 
 type RefKind string
 
 const (
-	Func     RefKind = "func"
-	Type     RefKind = "type"
-	RecvType RefKind = "recvtype"
-	Var      RefKind = "var"
-	Const    RefKind = "const"
+	Func      RefKind = "func"
+	Type      RefKind = "type"
+	RecvType  RefKind = "recvtype"
+	IfaceType RefKind = "ifacetype"
+	Field     RefKind = "field"
+	Var       RefKind = "var"
+	Const     RefKind = "const"
+	Label     RefKind = "label"
+	Pkg       RefKind = "pkg"
 )
 
 var RefKinds = []RefKind{
 	Func,
 	Type,
 	RecvType,
+	IfaceType,
+	Field,
 	Var,
 	Const,
+	Label,
+	Pkg,
 }
 
 type Direction string
@@ -52,303 +62,1293 @@ const (
 	Down   Direction = "down"
 	Up     Direction = "up"
 	Ignore Direction = "ignore"
+	// Either accepts both orderings but, unlike Ignore, still checks and reports the reference.
+	Either Direction = "either"
 )
 
 var Directions = []Direction{
 	Down,
 	Up,
 	Ignore,
+	Either,
 }
 
+// RefOrder holds the default direction of each RefKind.
+// It is copied into every analyzer and is never modified by flags.
 var RefOrder = map[RefKind]Direction{
-	Func:     Down,
-	Type:     Up,
-	RecvType: Up,
-	Var:      Up,
-	Const:    Up,
-}
-
-func init() {
-	Analyzer.Flags.BoolVar(&verbose, "verbose", false, `print all details`)
-	Analyzer.Flags.BoolVar(&colorize, "color", true, `colorize terminal`)
-	addDirectionFlag := func(kind RefKind, desc string) {
-		Analyzer.Flags.Func(
+	Func:      Down,
+	Type:      Up,
+	RecvType:  Up,
+	IfaceType: Up,
+	Field:     Ignore,
+	Var:       Up,
+	Const:     Up,
+	Label:     Ignore,
+	Pkg:       Ignore,
+}
+
+// refKindDocs describes the references covered by each RefKind.
+var refKindDocs = map[RefKind]string{
+	Func:      "references to functions and methods",
+	Type:      "type references, excluding references to the receiver type",
+	RecvType:  "references to the receiver type",
+	IfaceType: "interface method selections, as references to the interface type",
+	Field:     "references to struct fields",
+	Var:       "references to var declarations",
+	Const:     "references to const declarations",
+	Label:     "references to labels by goto statements",
+	Pkg:       "package name references, ordered against their import spec",
+}
+
+// Format selects how findings are written.
+type Format string
+
+const (
+	// FormatText reports findings as analysis diagnostics.
+	FormatText Format = "text"
+	// FormatJSON writes the findings of each package as a JSON array.
+	FormatJSON Format = "json"
+	// FormatSARIF writes the findings of the run as a SARIF 2.1.0 log to Options.SARIFOut.
+	FormatSARIF Format = "sarif"
+	// FormatGitHub writes the findings of each package as GitHub Actions annotations.
+	FormatGitHub Format = "github"
+	// FormatGrouped writes the findings of each package as text, grouped under a header per file.
+	FormatGrouped Format = "grouped"
+)
+
+var Formats = []Format{
+	FormatText,
+	FormatJSON,
+	FormatSARIF,
+	FormatGitHub,
+	FormatGrouped,
+}
+
+// Visibility restricts checking to exported or unexported identifiers.
+type Visibility string
+
+const (
+	VisibilityAll        Visibility = "all"
+	VisibilityExported   Visibility = "exported"
+	VisibilityUnexported Visibility = "unexported"
+)
+
+var Visibilities = []Visibility{
+	VisibilityAll,
+	VisibilityExported,
+	VisibilityUnexported,
+}
+
+// AliasMode selects the declaration that references through a type alias are ordered against.
+type AliasMode string
+
+const (
+	// AliasModeAlias orders references to an alias against the alias declaration.
+	AliasModeAlias AliasMode = "alias"
+	// AliasModeTarget orders references to an alias against the named type it stands for.
+	AliasModeTarget AliasMode = "target"
+)
+
+var AliasModes = []AliasMode{
+	AliasModeAlias,
+	AliasModeTarget,
+}
+
+// SameLineMode selects how references on the line of their definition are checked.
+type SameLineMode string
+
+const (
+	// SameLineOk accepts references on the line of their definition.
+	SameLineOk SameLineMode = "ok"
+	// SameLineCheck orders references on the line of their definition by column.
+	SameLineCheck SameLineMode = "check"
+)
+
+var SameLineModes = []SameLineMode{
+	SameLineOk,
+	SameLineCheck,
+}
+
+// FuncPosition is where init and main funcs must be declared in their file.
+type FuncPosition string
+
+const (
+	// FuncPositionFirst requires the func to be the first declaration after the imports.
+	FuncPositionFirst FuncPosition = "first"
+	// FuncPositionAfterVars requires the func to be declared right after the const and var
+	// declarations of the file.
+	FuncPositionAfterVars FuncPosition = "after-vars"
+	// FuncPositionAny accepts the func anywhere.
+	FuncPositionAny FuncPosition = "any"
+)
+
+var FuncPositions = []FuncPosition{
+	FuncPositionFirst,
+	FuncPositionAfterVars,
+	FuncPositionAny,
+}
+
+// ErrorCounter counts the errors reported by an analyzer across all packages of a run.
+type ErrorCounter struct {
+	n atomic.Int64
+}
+
+// Count returns the number of errors reported so far.
+func (c *ErrorCounter) Count() int { return int(c.n.Load()) }
+
+// sharedState is created once per analyzer and shared by all of its passes.
+type sharedState struct {
+	baseline baselineWriter
+	graph    graphWriter
+	sarif    sarifWriter
+	configs  configCache
+	// configDumped records that the -config-dump file was written.
+	configDumped atomic.Bool
+	// errors counts the errors printed so far, for -max-errors.
+	errors atomic.Int64
+}
+
+// Options configures a single refdir analyzer.
+type Options struct {
+	// RefOrder is the required direction of each kind of reference.
+	RefOrder map[RefKind]Direction
+	// TestRefOrder overrides RefOrder in _test.go files. Kinds missing from it use RefOrder.
+	TestRefOrder map[RefKind]Direction
+	// RefSeverity is the severity ordering errors of each kind are reported with, one of
+	// ReportSeverities. Kinds missing from it use SeverityError.
+	RefSeverity map[RefKind]Severity
+	// MinDistance is the smallest line distance at which an out-of-order reference is an error.
+	MinDistance int
+	// StrictIota orders references between constants of the same const block. By default
+	// such a block is treated as a unit.
+	StrictIota bool
+	// GroupTolerance accepts references within the same block of declarations, see declGroups.
+	GroupTolerance bool
+	// Visibility selects whether references to exported, unexported, or all identifiers are checked.
+	Visibility Visibility
+	// AliasMode selects whether references through a type alias are ordered against the
+	// alias or its target.
+	AliasMode AliasMode
+	// SameLine selects whether references on the line of their definition are accepted, or
+	// ordered by column as in var a, b = b, 1.
+	SameLine SameLineMode
+	// AllowMutualRecursion accepts func references between mutually recursive functions in either order.
+	AllowMutualRecursion bool
+	// VarInitCycles reports cycles among package-scope vars, through their initializers and
+	// the functions they call, as errors.
+	VarInitCycles bool
+	// MethodsAfterType reports methods declared above their receiver type in the same file
+	// as errors, whatever the direction of RecvType references.
+	MethodsAfterType bool
+	// MethodsGrouped reports methods separated from the previous method of their receiver
+	// type in the same file by other declarations as errors, once per type and file.
+	MethodsGrouped bool
+	// ConstructorAfterType reports constructors, funcs named ConstructorPrefix or
+	// ConstructorPrefix followed by an upper case letter that return a type of the package
+	// first, not declared right after that type in its file as errors.
+	ConstructorAfterType bool
+	ConstructorPrefix    string
+	// InitPosition and MainPosition report init funcs, and the main func of a main package,
+	// not declared at that position in their file as errors. Imports and other init and
+	// main funcs may always precede them.
+	InitPosition FuncPosition
+	MainPosition FuncPosition
+	// WarnShadowing reports, as info, local declarations that shadow a package-scope
+	// declaration whose references are ordered, as uses of the local are not checked.
+	WarnShadowing bool
+	// Baseline holds grandfathered errors, see LoadBaseline. Matching errors are reported as info.
+	Baseline map[BaselineEntry]bool
+	// BaselineOut is the path the errors of the run are written to as a new baseline.
+	BaselineOut string
+	// OnlyLines, if not nil, holds the lines of each file, keyed by absolute name, where
+	// ordering errors of references are reported. Elsewhere they are reported as info, so
+	// that a check of a diff only reports the errors on its lines.
+	OnlyLines map[string][]LineRange
+	// GraphOut is the path the reference graph of the run is written to, as a GraphViz DOT
+	// file of package-scope declarations with references colored by their result.
+	GraphOut string
+	// RespectNolint suppresses errors on lines covered by golangci-lint //nolint directives
+	// that apply to refdir, as when run by golangci-lint.
+	RespectNolint bool
+	// FirstUseOnly reports only the first out-of-order reference to each definition of a
+	// kind, and the later ones as info.
+	FirstUseOnly bool
+	// ExcludePaths are path.Match patterns of package directories that are not checked. A
+	// pattern matches the whole directory or any of its trailing paths, so "vendor/*" skips
+	// every package directly below a vendor directory.
+	ExcludePaths []string
+	// ExcludeNames matches the names of references that are never reported as errors.
+	ExcludeNames *regexp.Regexp
+	// IgnoreNames lists, per kind, definitions whose references are never reported as errors.
+	// A name matches the definition's name, optionally qualified by its receiver type for
+	// methods and by its package name, as in "helper", "T.String" or "mypkg.T.String".
+	// Config files add to these lists.
+	IgnoreNames map[RefKind][]string
+	// CrossFile orders references to definitions in other files of the package by file name.
+	CrossFile bool
+	// ReportCrossFileDensity reports the share of references to definitions in other files
+	// of the package, as a warning when it is above CrossFileDensityThreshold.
+	ReportCrossFileDensity bool
+	// CrossFileDensityThreshold is the share of cross-file references, from 0 to 1, above
+	// which ReportCrossFileDensity warns. It defaults to 0.5.
+	CrossFileDensityThreshold float64
+	// SpreadThreshold, if positive, reports as info the funcs that reference more than this
+	// many distinct package-scope declarations, a sign of a function doing too much.
+	SpreadThreshold int
+	// ReportUnreferenced reports, as info, the unexported package-scope declarations that are
+	// never referenced in the package, and whose order is then moot. With
+	// IncludeExportedUnreferenced, exported ones are reported as well.
+	ReportUnreferenced          bool
+	IncludeExportedUnreferenced bool
+	// Adjacency, if positive, also reports func references in the configured direction when
+	// the function has a single caller and is more than Adjacency top-level declarations away
+	// from it.
+	Adjacency int
+	// NearMiss reports, as info, references in the configured direction that are separated
+	// from their definition by a single top-level declaration unrelated to both.
+	NearMiss bool
+	// RecvFieldsAsType checks references to the fields of the receiver type inside its
+	// methods as RecvType references to the type declaration.
+	RecvFieldsAsType bool
+	// LocalDecls checks references to types, vars and consts declared in function bodies,
+	// including vars holding function literals, instead of skipping them. Parameters and
+	// results are never checked.
+	LocalDecls bool
+	// ReportFuncViolations writes the func declarations with func ordering errors in their
+	// body after each package, the most errors first.
+	ReportFuncViolations bool
+	// IgnoreClosures skips references inside function literals, which may run long after
+	// the surrounding code.
+	IgnoreClosures bool
+	// IncludeGenerated checks generated files instead of skipping them.
+	IncludeGenerated bool
+	// SuggestFixes attaches a fix moving the misplaced func or type declaration to errors.
+	SuggestFixes bool
+
+	Verbose bool
+	// Quiet prints nothing at all, not even summaries and reports, overriding Verbose and the
+	// output format. Errors are still counted by ErrorCounter, for the exit status.
+	Quiet bool
+	// SeverityFilter is the least severe kind of finding printed, one of Severities. When it
+	// is empty, errors and warnings are printed, and with Verbose all findings.
+	SeverityFilter Severity
+	// Colorize colors text output. Unless set by the color flag or a config file, colors are
+	// only used when writing to a terminal.
+	Colorize bool
+	// ColorError, ColorWarning, ColorInfo and ColorOk color findings of each severity in
+	// text output. Empty colors use the defaults: red, yellow, gray and green.
+	ColorError   color.Color
+	ColorWarning color.Color
+	ColorInfo    color.Color
+	ColorOk      color.Color
+	Format       Format
+	// SARIFOut is the path the findings of the run are written to with FormatSARIF, as a
+	// single SARIF log. It must be set with FormatSARIF.
+	SARIFOut string
+	// SARIFIncludeNotes keeps info and ok findings as note-level SARIF results.
+	SARIFIncludeNotes bool
+	// RelativePaths is the directory file names are printed relative to, in the findings of
+	// all formats but the positions the analysis driver prints in the text format, and in
+	// the positions within messages. Empty prints absolute file names, except in the github
+	// and grouped formats, which are relative to the working directory.
+	RelativePaths string
+	// Context prints this many source lines before and after each error, in the text and
+	// grouped formats. Zero prints none.
+	Context int
+	// Stream reports the findings of the text format as soon as they are found, unsorted,
+	// instead of sorting them by position once the package is checked.
+	Stream bool
+	// MaxErrors caps the number of errors printed by the run. Zero means no limit.
+	MaxErrors int
+	// Summary writes the number of findings per kind after each package.
+	Summary bool
+	// Score writes the given number of highest scoring errors after each package, see
+	// ScorePrinter. Zero disables the report.
+	Score int
+	// ScoreWeights holds the weight of each kind for Score. Kinds missing from it weigh 1.
+	ScoreWeights map[RefKind]float64
+	// Explain prints the effective direction of each kind as info findings before the
+	// findings of each package, whether or not Verbose is set. Only FormatText prints them.
+	Explain bool
+	// Config applies the nearest .refdir.yaml, .refdir.yml or .refdir.json above each package.
+	// Its settings override these options, except those set explicitly by flags.
+	Config bool
+	// ConfigDump is the path the effective configuration is written to, in the format of
+	// config files, instead of checking packages. It is that of the first package of the run.
+	ConfigDump string
+	// Output receives findings for formats other than FormatText and FormatSARIF. Nil means
+	// stdout.
+	Output io.Writer
+	// ErrorCounter, if set, counts the errors reported by the analyzer.
+	ErrorCounter *ErrorCounter
+	// Log receives summaries and other reports that are not tied to a position. Nil means stderr.
+	Log io.Writer
+
+	// shared holds state accumulated across the packages of a run.
+	shared *sharedState
+	// flags is the flag set of the analyzer, used to tell which options were set explicitly.
+	flags *flag.FlagSet
+	// colorSet records that Colorize was set by a config file.
+	colorSet bool
+	// ignoreNames maps the names in IgnoreNames and in the config file to where they were
+	// listed, per kind.
+	ignoreNames map[RefKind]map[string]string
+}
+
+// DefaultOptions returns the options used by the default Analyzer.
+func DefaultOptions() Options {
+	return Options{
+		RefOrder:                  maps.Clone(RefOrder),
+		Visibility:                VisibilityAll,
+		AliasMode:                 AliasModeAlias,
+		SameLine:                  SameLineOk,
+		CrossFileDensityThreshold: 0.5,
+		Colorize:                  true,
+		Format:                    FormatText,
+		Config:                    true,
+		RespectNolint:             true,
+		ConstructorPrefix:         "New",
+		InitPosition:              FuncPositionAny,
+		MainPosition:              FuncPositionAny,
+	}
+}
+
+// NewWithOptions returns a fresh analyzer that owns a copy of opts.
+// Its flags write into that copy only, so analyzers never share configuration.
+// Kinds missing from opts.RefOrder use their default direction. Invalid options fail each
+// pass of the analyzer, see Options.Validate.
+func NewWithOptions(opts Options) *analysis.Analyzer {
+	opts.init()
+
+	a := &analysis.Analyzer{
+		Name:     analyzerName,
+		Doc:      doc(opts.RefOrder),
+		Run:      opts.run,
+		Flags:    flag.FlagSet{},
+		Requires: []*analysis.Analyzer{inspect.Analyzer},
+	}
+	opts.registerFlags(&a.Flags)
+	opts.flags = &a.Flags
+	return a
+}
+
+// doc returns the documentation of an analyzer checking references in the directions of
+// order, listing them so that help output follows changes to the defaults.
+func doc(order map[RefKind]Direction) string {
+	var b strings.Builder
+	b.WriteString("Report potential reference-to-declaration ordering issues\n\n")
+	b.WriteString("Each kind of reference is checked in a direction: down when references must\n")
+	b.WriteString("come before their definition, up when after it, either when both are accepted,\n")
+	b.WriteString("and ignore when it is not checked. The directions, set with -<kind>-dir, are:\n\n")
+	for _, kind := range RefKinds {
+		fmt.Fprintf(&b, "\t%-10s %-7s %s\n", kind, order[kind], refKindDocs[kind])
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Check runs the analysis on a single package and returns all of its findings, including
+// ok and info ones, without printing or reporting anything. Unlike the analyzers returned
+// by NewWithOptions, it does not require the inspect analyzer to have run, and it ignores
+// config files.
+// Invalid options are returned as an error, see Options.Validate.
+func Check(pass *analysis.Pass, opts Options) ([]Finding, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	opts.init()
+	return opts.check(pass, nil).findings, nil
+}
+
+// init gives o its own RefOrder, TestRefOrder and RefSeverity with defaults for missing
+// kinds, and fresh shared state.
+func (o *Options) init() {
+	order := maps.Clone(RefOrder)
+	maps.Copy(order, o.RefOrder)
+	o.RefOrder = order
+	o.TestRefOrder = maps.Clone(o.TestRefOrder)
+	if o.TestRefOrder == nil {
+		o.TestRefOrder = make(map[RefKind]Direction)
+	}
+	severity := make(map[RefKind]Severity, len(RefKinds))
+	for _, kind := range RefKinds {
+		severity[kind] = SeverityError
+	}
+	maps.Copy(severity, o.RefSeverity)
+	o.RefSeverity = severity
+	o.ignoreNames = make(map[RefKind]map[string]string, len(o.IgnoreNames))
+	addIgnoreNames(o.ignoreNames, o.IgnoreNames, "options")
+	o.shared = &sharedState{}
+}
+
+func (o *Options) registerFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&o.Verbose, "verbose", o.Verbose, `print all details`)
+	fs.BoolVar(&o.Quiet, "quiet", o.Quiet, `print nothing, only fail through the exit status on errors`)
+	fs.BoolVar(&o.Colorize, "color", o.Colorize, `colorize terminal`)
+	for _, c := range []struct {
+		severity Severity
+		color    *color.Color
+	}{
+		{SeverityError, &o.ColorError},
+		{SeverityWarning, &o.ColorWarning},
+		{SeverityInfo, &o.ColorInfo},
+		{SeverityOk, &o.ColorOk},
+	} {
+		fs.Func(
+			"color-"+string(c.severity),
+			fmt.Sprintf("color of %s findings, one of %v or a 256-color code", c.severity, color.Names),
+			func(s string) error {
+				v, ok := color.Lookup(s)
+				if !ok {
+					return fmt.Errorf("must be one of %v or a number from 0 to 255", color.Names)
+				}
+				*c.color = v
+				return nil
+			},
+		)
+	}
+	fs.Func(
+		"severity-filter",
+		fmt.Sprintf("print only findings at least this severe, one of %v (default: warning, info with -verbose)", Severities),
+		func(s string) error {
+			if err := oneOf(Severity(s), Severities); err != nil {
+				return err
+			}
+			o.SeverityFilter = Severity(s)
+			return nil
+		},
+	)
+	fs.IntVar(
+		&o.MinDistance,
+		"min-distance",
+		o.MinDistance,
+		`only report out-of-order references at least this many lines from their definition`,
+	)
+	fs.BoolVar(
+		&o.StrictIota,
+		"strict-iota",
+		o.StrictIota,
+		`order references between constants of the same const block`,
+	)
+	fs.BoolVar(
+		&o.GroupTolerance,
+		"group-tolerance",
+		o.GroupTolerance,
+		`accept references within the same declaration block or run of declarations without blank lines`,
+	)
+	fs.Func(
+		"visibility",
+		fmt.Sprintf("check references to identifiers with this visibility, one of %v (default %s)", Visibilities, o.Visibility),
+		func(s string) error {
+			if err := oneOf(Visibility(s), Visibilities); err != nil {
+				return err
+			}
+			o.Visibility = Visibility(s)
+			return nil
+		},
+	)
+	fs.Func(
+		"alias-mode",
+		fmt.Sprintf("order references to type aliases against the %v declaration (default %s)", AliasModes, o.AliasMode),
+		func(s string) error {
+			if err := oneOf(AliasMode(s), AliasModes); err != nil {
+				return err
+			}
+			o.AliasMode = AliasMode(s)
+			return nil
+		},
+	)
+	fs.Func(
+		"same-line",
+		fmt.Sprintf("accept references on the line of their definition, or check their order by column, one of %v (default %s)", SameLineModes, o.SameLine),
+		func(s string) error {
+			if err := oneOf(SameLineMode(s), SameLineModes); err != nil {
+				return err
+			}
+			o.SameLine = SameLineMode(s)
+			return nil
+		},
+	)
+	fs.BoolVar(
+		&o.AllowMutualRecursion,
+		"allow-mutual-recursion",
+		o.AllowMutualRecursion,
+		`accept references between mutually recursive functions in either order`,
+	)
+	fs.BoolVar(
+		&o.VarInitCycles,
+		"var-init-cycles",
+		o.VarInitCycles,
+		`report initialization cycles among package-scope vars, including through interface method calls`,
+	)
+	fs.BoolVar(
+		&o.MethodsAfterType,
+		"methods-after-type",
+		o.MethodsAfterType,
+		`report methods declared above their receiver type in the same file`,
+	)
+	fs.BoolVar(
+		&o.MethodsGrouped,
+		"methods-grouped",
+		o.MethodsGrouped,
+		`report methods not declared next to the other methods of their receiver type in the same file`,
+	)
+	fs.BoolVar(
+		&o.ConstructorAfterType,
+		"constructor-after-type",
+		o.ConstructorAfterType,
+		`report constructors not declared right after the type they return`,
+	)
+	fs.StringVar(
+		&o.ConstructorPrefix,
+		"constructor-prefix",
+		o.ConstructorPrefix,
+		`with -constructor-after-type, the name prefix of constructors`,
+	)
+	for _, p := range []struct {
+		name     string
+		position *FuncPosition
+	}{
+		{"init", &o.InitPosition},
+		{"main", &o.MainPosition},
+	} {
+		fs.Func(
+			p.name+"-position",
+			fmt.Sprintf("required position of %s funcs in their file, one of %v (default %s)", p.name, FuncPositions, *p.position),
+			func(s string) error {
+				if err := oneOf(FuncPosition(s), FuncPositions); err != nil {
+					return err
+				}
+				*p.position = FuncPosition(s)
+				return nil
+			},
+		)
+	}
+	fs.BoolVar(
+		&o.WarnShadowing,
+		"warn-shadowing",
+		o.WarnShadowing,
+		`report, as info, local declarations shadowing a package-scope declaration with ordered references`,
+	)
+	fs.Func("baseline", `path of a baseline file whose errors are reported as info`, func(s string) error {
+		baseline, err := LoadBaseline(s)
+		if err != nil {
+			return err
+		}
+		o.Baseline = baseline
+		return nil
+	})
+	fs.Func(
+		"only-lines",
+		`comma separated file:start-end line ranges, as in "a.go:3-9,b.go:12", outside of which errors are info`,
+		func(s string) error {
+			if o.OnlyLines == nil {
+				o.OnlyLines = make(map[string][]LineRange)
+			}
+			return parseLineRanges(s, o.OnlyLines)
+		},
+	)
+	fs.StringVar(&o.BaselineOut, "baseline-out", o.BaselineOut, `write the errors of the run to this baseline file`)
+	fs.StringVar(
+		&o.GraphOut,
+		"graph-out",
+		o.GraphOut,
+		`write the references between package-scope declarations to this GraphViz DOT file`,
+	)
+	fs.BoolVar(
+		&o.RespectNolint,
+		"respect-nolint",
+		o.RespectNolint,
+		`suppress errors on lines with a //nolint or //nolint:refdir directive, on the line or the line before`,
+	)
+	fs.BoolVar(
+		&o.FirstUseOnly,
+		"first-use-only",
+		o.FirstUseOnly,
+		`report only the first out-of-order reference to each definition, later ones as info`,
+	)
+	fs.Func("exclude-paths", `comma separated glob patterns of package directories to skip, as in "vendor/*,gen"`, func(s string) error {
+		for pattern := range strings.SplitSeq(s, ",") {
+			pattern = strings.TrimSpace(pattern)
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+			o.ExcludePaths = append(o.ExcludePaths, pattern)
+		}
+		return nil
+	})
+	fs.Func("exclude-names", `regexp of reference names that are never reported as errors`, func(s string) error {
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return fmt.Errorf("invalid regexp: %w", err)
+		}
+		o.ExcludeNames = re
+		return nil
+	})
+	fs.BoolVar(
+		&o.CrossFile,
+		"cross-file",
+		o.CrossFile,
+		`order references across files of a package, with files ordered by base name`,
+	)
+	fs.BoolVar(
+		&o.ReportCrossFileDensity,
+		"report-cross-file-density",
+		o.ReportCrossFileDensity,
+		`report the share of references to definitions in other files of each package`,
+	)
+	fs.Float64Var(
+		&o.CrossFileDensityThreshold,
+		"cross-file-density-threshold",
+		o.CrossFileDensityThreshold,
+		`with -report-cross-file-density, warn when the share of cross-file references is above this`,
+	)
+	fs.IntVar(
+		&o.SpreadThreshold,
+		"spread-threshold",
+		o.SpreadThreshold,
+		`report, as info, funcs referencing more than this many distinct package-scope declarations, 0 means off`,
+	)
+	fs.BoolVar(
+		&o.ReportUnreferenced,
+		"report-unreferenced",
+		o.ReportUnreferenced,
+		`report, as info, unexported package-scope declarations never referenced in their package`,
+	)
+	fs.BoolVar(
+		&o.IncludeExportedUnreferenced,
+		"include-exported-unreferenced",
+		o.IncludeExportedUnreferenced,
+		`with -report-unreferenced, report exported declarations as well`,
+	)
+	fs.IntVar(
+		&o.Adjacency,
+		"adjacency",
+		o.Adjacency,
+		`report funcs with a single caller more than this many declarations away from it, 0 means off`,
+	)
+	fs.BoolVar(
+		&o.NearMiss,
+		"near-miss",
+		o.NearMiss,
+		`suggest, as info, moving a single unrelated declaration that separates a reference from its definition`,
+	)
+	fs.BoolVar(
+		&o.RecvFieldsAsType,
+		"recv-fields-as-type",
+		o.RecvFieldsAsType,
+		`check references to receiver fields in methods as recvtype references to the receiver type`,
+	)
+	fs.BoolVar(
+		&o.LocalDecls,
+		"local-decls",
+		o.LocalDecls,
+		`check references to types, vars and consts declared in function bodies`,
+	)
+	fs.BoolVar(
+		&o.ReportFuncViolations,
+		"report-func-violations",
+		o.ReportFuncViolations,
+		`print the funcs with the most func ordering errors in their body for each package`,
+	)
+	fs.BoolVar(
+		&o.IgnoreClosures,
+		"ignore-closures",
+		o.IgnoreClosures,
+		`skip references inside function literals`,
+	)
+	fs.BoolVar(
+		&o.IncludeGenerated,
+		"include-generated",
+		o.IncludeGenerated,
+		`check generated files instead of skipping them`,
+	)
+	fs.BoolVar(
+		&o.SuggestFixes,
+		"suggest-fixes",
+		o.SuggestFixes,
+		`suggest moving misplaced func and type declarations (apply with -fix)`,
+	)
+	fs.Func("format", fmt.Sprintf("output format, one of %v (default %s)", Formats, o.Format), func(s string) error {
+		if err := oneOf(Format(s), Formats); err != nil {
+			return err
+		}
+		o.Format = Format(s)
+		return nil
+	})
+	fs.BoolFunc(
+		"relative-paths",
+		`print file names relative to the working directory, or to the directory given as -relative-paths=dir`,
+		func(s string) error {
+			switch s {
+			case "true":
+				o.RelativePaths = "."
+			case "false":
+				o.RelativePaths = ""
+			default:
+				o.RelativePaths = s
+			}
+			return nil
+		},
+	)
+	fs.IntVar(&o.Context, "context", o.Context, `print this many source lines around each error, in the text and grouped formats`)
+	fs.BoolVar(
+		&o.Config,
+		"config",
+		o.Config,
+		`apply the nearest .refdir.yaml, .refdir.yml or .refdir.json above each package`,
+	)
+	fs.StringVar(
+		&o.ConfigDump,
+		"config-dump",
+		o.ConfigDump,
+		`write the effective configuration to this .yaml or .json file and check nothing`,
+	)
+	fs.BoolVar(
+		&o.Stream,
+		"stream",
+		o.Stream,
+		`with -format=text, report findings as they are found instead of sorting them by position`,
+	)
+	fs.IntVar(&o.MaxErrors, "max-errors", o.MaxErrors, `stop printing errors after this many, 0 means no limit`)
+	fs.BoolVar(&o.Summary, "summary", o.Summary, `print the number of findings per kind for each package`)
+	fs.IntVar(&o.Score, "score", o.Score, `print the N errors with the highest line distance x kind weight for each package`)
+	fs.Func(
+		"score-weights",
+		`weights of kinds for -score, as in func=2,type=0.5 (default 1 for each kind)`,
+		func(s string) error {
+			weights, err := parseScoreWeights(s)
+			if err != nil {
+				return err
+			}
+			o.ScoreWeights = weights
+			return nil
+		},
+	)
+	fs.BoolVar(
+		&o.Explain,
+		"explain",
+		o.Explain,
+		`with -format=text, print the direction of each kind, after flags and config files are applied, for each package`,
+	)
+	fs.StringVar(&o.SARIFOut, "sarif-out", o.SARIFOut, `with -format=sarif, write the findings of the run to this SARIF file`)
+	fs.BoolVar(
+		&o.SARIFIncludeNotes,
+		"sarif-include-notes",
+		o.SARIFIncludeNotes,
+		`with -format=sarif, also report info and ok findings as notes`,
+	)
+	for _, kind := range RefKinds {
+		fs.Func(
 			string(kind)+"-dir",
-			fmt.Sprintf("%s (default %s)", desc, RefOrder[kind]),
+			fmt.Sprintf("direction of %s (default %s)", refKindDocs[kind], o.RefOrder[kind]),
 			func(s string) error {
-				switch dir := Direction(s); dir {
-				case Down, Up, Ignore:
-					RefOrder[kind] = dir
-					return nil
-				default:
-					return fmt.Errorf("must be %s, %s, or %s", Up, Down, Ignore)
+				var dir Direction
+				if err := dir.Set(s); err != nil {
+					return err
 				}
+				o.RefOrder[kind] = dir
+				return nil
+			},
+		)
+		fs.Func(
+			"test-"+string(kind)+"-dir",
+			fmt.Sprintf("direction of %s in _test.go files (default: as %s-dir)", refKindDocs[kind], kind),
+			func(s string) error {
+				var dir Direction
+				if err := dir.Set(s); err != nil {
+					return err
+				}
+				o.TestRefOrder[kind] = dir
+				return nil
+			},
+		)
+		fs.Func(
+			string(kind)+"-severity",
+			fmt.Sprintf("severity of ordering errors of %s, one of %v (default %s)",
+				refKindDocs[kind], ReportSeverities, o.RefSeverity[kind]),
+			func(s string) error {
+				if err := oneOf(Severity(s), ReportSeverities); err != nil {
+					return err
+				}
+				o.RefSeverity[kind] = Severity(s)
+				return nil
 			},
 		)
 	}
-	addDirectionFlag(Func, "direction of references to functions and methods")
-	addDirectionFlag(Type, "direction of type references, excluding references to the receiver type")
-	addDirectionFlag(RecvType, "direction of references to the receiver type")
-	addDirectionFlag(Var, "direction of references to var declarations")
-	addDirectionFlag(Const, "direction of references to const declarations")
 }
 
-func run(pass *analysis.Pass) (any, error) {
-	var printer Printer = SimplePrinter{Pass: pass}
-	if colorize {
-		printer = ColorPrinter{
-			Pass:       pass,
-			ColorError: color.Red,
-			ColorInfo:  color.Gray,
-			ColorOk:    color.Green,
-		}
-	}
-	printer = VerbosePrinter{Verbose: verbose, Printer: printer}
-	printer = &SortedPrinter{Pass: pass, Printer: printer}
-	defer printer.Flush()
-
-	analysisInspector, ok := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
-	if !ok {
-		return nil, errors.New("could not get analyzer")
-	}
-
-	check := func(ref *ast.Ident, def token.Pos, kind RefKind) {
-		if !def.IsValid() {
-			// So far only seen on calls to Error method of error interface.
-			printer.Info(ref.Pos(), fmt.Sprintf("got invalid definition position for %q", ref.Name))
-			return
-		}
-
-		if RefOrder[kind] == Ignore {
-			printer.Info(ref.Pos(), fmt.Sprintf("%s reference %s ignored by options", kind, ref.Name))
-			return
-		}
-
-		if pass.Fset.File(ref.Pos()).Name() != pass.Fset.File(def).Name() {
-			printer.Info(
-				ref.Pos(),
-				fmt.Sprintf(
-					`%s reference %s is to definition in separate file (%s)`,
-					kind,
-					ref.Name,
-					pass.Fset.Position(def),
-				),
-			)
-			return
-		}
-
-		refLine, defLine := pass.Fset.Position(ref.Pos()).Line, pass.Fset.Position(def).Line
-		if refLine == defLine {
-			printer.Ok(
-				ref.Pos(),
-				fmt.Sprintf(
-					`%s reference %s is on same line as definition (%s)`,
-					kind,
-					ref.Name,
-					pass.Fset.Position(def),
-				),
-			)
-			return
-		}
-
-		refBeforeDef := refLine < defLine
-		order := "before"
-		if !refBeforeDef {
-			order = "after"
-		}
-		var message string
-		if verbose {
-			message = fmt.Sprintf(
-				`%s reference %s is %s definition (%s)`,
-				kind,
-				ref.Name,
-				order,
-				pass.Fset.Position(def),
-			)
-		} else {
-			message = fmt.Sprintf(`%s reference %s is %s definition`, kind, ref.Name, order)
-		}
-
-		if orderOk := refBeforeDef == (RefOrder[kind] == Down); orderOk {
-			printer.Ok(ref.Pos(), message)
-		} else {
-			printer.Error(ref.Pos(), message)
-		}
-	}
-
-	// Map selector identifiers (the "Sel" in x.Sel) to their selections so we can
-	// distinguish interface method selections from concrete ones.
-	selOfIdent := make(map[*ast.Ident]*types.Selection)
-
-	// State for keeping track of the receiver type.
-	// No need for a stack as method declarations can only be at file scope.
-	var (
-		funcDecl       *ast.FuncDecl
-		recvType       *types.TypeName
-		beforeFuncType bool
-	)
-
-	analysisInspector.Nodes(nil, func(n ast.Node, push bool) (proceed bool) {
-		if !push {
-			if funcDecl == n {
-				funcDecl = nil
-				recvType = nil
-			}
-			return true
+// parseScoreWeights parses comma separated kind=weight settings, as in "func=2,type=0.5".
+func parseScoreWeights(s string) (map[RefKind]float64, error) {
+	weights := make(map[RefKind]float64)
+	for setting := range strings.SplitSeq(s, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(setting), "=")
+		if !ok || !slices.Contains(RefKinds, RefKind(name)) {
+			return nil, fmt.Errorf("invalid setting %q, must be kind=weight with a kind of %v", setting, RefKinds)
+		}
+		weight, err := strconv.ParseFloat(value, 64)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight %q for %s, must be a non-negative number", value, name)
 		}
+		weights[RefKind(name)] = weight
+	}
+	return weights, nil
+}
 
-		switch node := n.(type) {
-		case *ast.File:
-			if ast.IsGenerated(node) {
-				printer.Info(node.Pos(), "skipping generated file")
-				return false
-			}
+// run checks a single package and prints its findings. It only reads o, so one analyzer
+// may safely run concurrently across packages once its flags have been parsed.
+func (o *Options) run(pass *analysis.Pass) (any, error) {
+	opts, findings, err := o.withConfig(pass)
+	if err != nil {
+		return nil, err
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+	if opts.ConfigDump != "" {
+		//nolint:nilnil // Done.
+		return nil, opts.dumpConfigOnce()
+	}
+	if pattern, ok := opts.excludedPath(pass); ok {
+		printer := opts.newFormatPrinter(pass)
+		printer.Info(Finding{
+			Pos:     pass.Files[0].Package,
+			Message: fmt.Sprintf("skipping package excluded by -exclude-paths pattern %q", pattern),
+		})
+		printer.Flush()
+		//nolint:nilnil // Done.
+		return nil, opts.writeSARIF()
+	}
+	// Other formats would write the explanation as a document of its own.
+	if opts.Explain && cmp.Or(opts.Format, FormatText) == FormatText {
+		explained := *opts
+		explained.Verbose = true
+		explained.SeverityFilter = ""
+		printer := explained.newFormatPrinter(pass)
+		for _, f := range opts.explain(pass) {
+			f.PrintTo(printer)
+		}
+		printer.Flush()
+	}
 
-		case *ast.SelectorExpr:
-			if sel := pass.TypesInfo.Selections[node]; sel != nil {
-				selOfIdent[node.Sel] = sel
-			}
+	printer := opts.newPrinter(pass)
+	for _, f := range findings {
+		f.PrintTo(printer)
+	}
+	var stream func(Finding)
+	if opts.Stream {
+		stream = func(f Finding) { f.PrintTo(printer) }
+	}
+	result := opts.check(pass, stream)
+	if !opts.Stream {
+		for _, f := range result.findings {
+			f.PrintTo(printer)
+		}
+	}
+	printer.Flush()
+	if len(result.funcViolations) > 0 && !opts.Quiet {
+		opts.writeFuncViolations(pass, result.funcViolations)
+	}
 
-		case *ast.FuncDecl:
-			if funcDecl == nil {
-				funcDecl = node
-				beforeFuncType = true
-			}
+	if opts.BaselineOut != "" {
+		if err := opts.shared.baseline.add(opts.BaselineOut, result.baselineOut); err != nil {
+			return nil, fmt.Errorf("failed to write baseline: %w", err)
+		}
+	}
+	if opts.GraphOut != "" {
+		if err := opts.shared.graph.add(opts.GraphOut, pass, result.findings); err != nil {
+			return nil, fmt.Errorf("failed to write graph: %w", err)
+		}
+	}
 
-		case *ast.FuncType:
-			beforeFuncType = false
+	//nolint:nilnil // Done.
+	return nil, opts.writeSARIF()
+}
 
-		case *ast.Ident:
-			// If this ident is a definition or otherwise has no associated use,
-			// skip it to avoid noisy "unexpected ident" messages.
-			obj := pass.TypesInfo.Uses[node]
-			if obj == nil {
+// writeSARIF rewrites the -sarif-out file with the findings printed so far with -format=sarif.
+func (o *Options) writeSARIF() error {
+	if o.Format != FormatSARIF || o.Quiet {
+		return nil
+	}
+	if err := o.shared.sarif.write(o.SARIFOut); err != nil {
+		return fmt.Errorf("failed to write SARIF log: %w", err)
+	}
+	return nil
+}
+
+// excludedPath returns the first of ExcludePaths matching the directory of pass.
+func (o *Options) excludedPath(pass *analysis.Pass) (string, bool) {
+	if len(o.ExcludePaths) == 0 || len(pass.Files) == 0 {
+		return "", false
+	}
+	file := pass.Fset.File(pass.Files[0].Pos())
+	if file == nil {
+		return "", false
+	}
+	dir := filepath.ToSlash(filepath.Dir(file.Name()))
+	for _, pattern := range o.ExcludePaths {
+		for rest := dir; ; {
+			if ok, _ := path.Match(pattern, rest); ok {
+				return pattern, true
+			}
+			_, after, found := strings.Cut(rest, "/")
+			if !found {
 				break
 			}
+			rest = after
+		}
+	}
+	return "", false
+}
 
-			switch def := obj.(type) {
-			case *types.Var:
-				def = def.Origin()
-				switch {
-				case def.IsField():
-					printer.Info(node.Pos(), fmt.Sprintf("skipping var ident %s for field %s", node.Name,
-						pass.Fset.Position(def.Pos())))
-				case def.Parent() != def.Pkg().Scope():
-					printer.Info(node.Pos(), fmt.Sprintf("skipping var ident %s with inner parent scope %s", node.Name,
-						pass.Fset.Position(def.Parent().Pos())))
-				default:
-					check(node, def.Pos(), Var)
-				}
-			case *types.Const:
-				if def.Parent() != def.Pkg().Scope() {
-					pos := pass.Fset.Position(def.Parent().Pos())
-					i := fmt.Sprintf("skipping var ident %s with inner parent scope %s", node.Name, pos)
-					printer.Info(node.Pos(), i)
-				} else {
-					check(node, def.Pos(), Const)
-				}
+// Validate reports every invalid setting of o: unknown kinds, directions, severities or
+// other enumerated values, negative counts and weights, and a density threshold outside
+// [0, 1]. Empty enumerated values select their defaults and are valid. Flags and config
+// files only set valid values, but options built in code are checked when they are used.
+func (o *Options) Validate() error {
+	var errs []error
+	checkKind := func(kind RefKind) {
+		if err := oneOf(kind, RefKinds); err != nil {
+			errs = append(errs, fmt.Errorf("kind %q: %w", kind, err))
+		}
+	}
+	for _, kind := range slices.Sorted(maps.Keys(o.RefOrder)) {
+		checkKind(kind)
+		if err := oneOf(o.RefOrder[kind], Directions); err != nil {
+			errs = append(errs, fmt.Errorf("%s-dir: %w", kind, err))
+		}
+	}
+	for _, kind := range slices.Sorted(maps.Keys(o.TestRefOrder)) {
+		checkKind(kind)
+		if err := oneOf(o.TestRefOrder[kind], Directions); err != nil {
+			errs = append(errs, fmt.Errorf("test-%s-dir: %w", kind, err))
+		}
+	}
+	for _, kind := range slices.Sorted(maps.Keys(o.RefSeverity)) {
+		checkKind(kind)
+		if err := oneOf(o.RefSeverity[kind], ReportSeverities); err != nil {
+			errs = append(errs, fmt.Errorf("%s-severity: %w", kind, err))
+		}
+	}
+	for _, kind := range slices.Sorted(maps.Keys(o.IgnoreNames)) {
+		checkKind(kind)
+	}
+	for _, pattern := range o.ExcludePaths {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Errorf("exclude-paths: pattern %q: %w", pattern, err))
+		}
+	}
+	for _, kind := range slices.Sorted(maps.Keys(o.ScoreWeights)) {
+		checkKind(kind)
+		if o.ScoreWeights[kind] < 0 {
+			errs = append(errs, fmt.Errorf("score weight of %s: must not be negative", kind))
+		}
+	}
+	if o.Visibility != "" {
+		if err := oneOf(o.Visibility, Visibilities); err != nil {
+			errs = append(errs, fmt.Errorf("visibility: %w", err))
+		}
+	}
+	if o.AliasMode != "" {
+		if err := oneOf(o.AliasMode, AliasModes); err != nil {
+			errs = append(errs, fmt.Errorf("alias-mode: %w", err))
+		}
+	}
+	if o.SameLine != "" {
+		if err := oneOf(o.SameLine, SameLineModes); err != nil {
+			errs = append(errs, fmt.Errorf("same-line: %w", err))
+		}
+	}
+	if o.InitPosition != "" {
+		if err := oneOf(o.InitPosition, FuncPositions); err != nil {
+			errs = append(errs, fmt.Errorf("init-position: %w", err))
+		}
+	}
+	if o.MainPosition != "" {
+		if err := oneOf(o.MainPosition, FuncPositions); err != nil {
+			errs = append(errs, fmt.Errorf("main-position: %w", err))
+		}
+	}
+	if o.Format != "" {
+		if err := oneOf(o.Format, Formats); err != nil {
+			errs = append(errs, fmt.Errorf("format: %w", err))
+		}
+	}
+	if o.Format == FormatSARIF && o.SARIFOut == "" {
+		errs = append(errs, errors.New("format sarif: sarif-out must be set"))
+	}
+	if o.SeverityFilter != "" {
+		if err := oneOf(o.SeverityFilter, Severities); err != nil {
+			errs = append(errs, fmt.Errorf("severity-filter: %w", err))
+		}
+	}
+	for _, count := range []struct {
+		name string
+		n    int
+	}{{"min-distance", o.MinDistance}, {"max-errors", o.MaxErrors}, {"score", o.Score}, {"adjacency", o.Adjacency}, {"spread-threshold", o.SpreadThreshold}, {"context", o.Context}} {
+		if count.n < 0 {
+			errs = append(errs, fmt.Errorf("%s: must not be negative", count.name))
+		}
+	}
+	if o.ConstructorAfterType && o.ConstructorPrefix == "" {
+		errs = append(errs, errors.New("constructor-prefix: must not be empty"))
+	}
+	if o.CrossFileDensityThreshold < 0 || o.CrossFileDensityThreshold > 1 {
+		errs = append(errs, errors.New("cross-file-density-threshold: must be between 0 and 1"))
+	}
+	return errors.Join(errs...)
+}
 
-			case *types.Func:
-				def = def.Origin()
-				// Allow direct self-recursion (call to the function we're inside).
-				if funcDecl != nil {
-					curr, ok := pass.TypesInfo.Defs[funcDecl.Name].(*types.Func)
-					if ok && curr != nil && curr.Origin() == def {
-						// For a recursive call, pass.TypesInfo.Uses[node] returns the current function’s object;
-						// comparing its Origin() to the current func’s Origin() lets us detect direct recursion even
-						// with generics instantiation.
-						break
-					}
-				}
+// writeFuncViolations writes a report like
+//
+//	refdir: example.com/pkg: func ordering errors per func:
+//	  3 a.go:10:6: run
+//	  1 a.go:42:15: (*T).Set
+func (o *Options) writeFuncViolations(pass *analysis.Pass, violations []funcViolation) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s: func ordering errors per func:\n", analyzerName, pass.Pkg.Path())
+	base := o.baseDir()
+	for _, v := range violations {
+		pos := pass.Fset.Position(v.pos)
+		pos.Filename = relativePath(base, pos)
+		fmt.Fprintf(&b, "  %d %s: %s\n", v.count, pos, v.name)
+	}
+	_, _ = io.WriteString(o.log(), b.String())
+}
 
-				// Handle interface method selections as type references.
-				// If this is a method selection, and the receiver is an interface type,
-				// treat it as a reference to the interface type (not a function).
-				if sel := selOfIdent[node]; sel != nil {
-					recv := sel.Recv()
-					// Unwrap pointers.
-					for {
-						if p, ok := recv.(*types.Pointer); ok {
-							recv = p.Elem()
-							continue
-						}
-						break
-					}
-					handled := false
-					switch rt := recv.(type) {
-					case *types.Named:
-						if _, ok := rt.Underlying().(*types.Interface); ok {
-							// Count this as a type reference to the named interface.
-							check(node, rt.Obj().Pos(), Type)
-							handled = true
-						}
-					case *types.Interface:
-						// Unnamed interface type; nothing to order against at package scope.
-						i := fmt.Sprintf("skipping interface method reference %s on unnamed interface type", node.Name)
-						printer.Info(node.Pos(), i)
-						handled = true
-					case *types.TypeParam:
-						// Method selected via a type parameter's interface constraint.
-						n := rt.Obj().Name()
-						pos := fmt.Sprintf("skipping method reference %s on type parameter %s", node.Name, n)
-						printer.Info(node.Pos(), pos)
-						handled = true
-					}
-					if handled {
-						break
-					}
-				}
+// oneOf returns an error listing valid unless it contains v.
+func oneOf[T comparable](v T, valid []T) error {
+	if !slices.Contains(valid, v) {
+		return fmt.Errorf("must be one of %v", valid)
+	}
+	return nil
+}
 
-				if def.Parent() != nil && def.Parent() != def.Pkg().Scope() {
-					pos := pass.Fset.Position(def.Parent().Pos())
-					i := fmt.Sprintf("skipping func ident %s with inner parent scope %s", node.Name, pos)
-					printer.Info(node.Pos(), i)
-				} else {
-					check(node, def.Pos(), Func)
-				}
+// explain describes the effective direction of each kind as info findings at the package
+// clause of the first file. Test file directions are included where they differ.
+func (o *Options) explain(pass *analysis.Pass) []Finding {
+	if len(pass.Files) == 0 {
+		return nil
+	}
+	findings := make([]Finding, 0, len(RefKinds))
+	for _, kind := range RefKinds {
+		msg := fmt.Sprintf("policy: %s-dir=%s", kind, o.RefOrder[kind])
+		if dir, ok := o.TestRefOrder[kind]; ok && dir != o.RefOrder[kind] {
+			msg += fmt.Sprintf(", test-%s-dir=%s", kind, dir)
+		}
+		findings = append(findings, Finding{
+			Pos:       pass.Files[0].Package,
+			Kind:      kind,
+			Direction: o.RefOrder[kind],
+			Severity:  SeverityInfo,
+			Message:   msg + " (" + refKindDocs[kind] + ")",
+		})
+	}
+	return findings
+}
 
-			case *types.TypeName:
-				if def.Pkg() == nil {
-					printer.Info(node.Pos(), "skipping predeclared type "+node.Name)
-					break
-				}
-				if def.Parent() != def.Pkg().Scope() {
-					pos := pass.Fset.Position(def.Parent().Pos())
-					i := fmt.Sprintf("skipping type ident %s with inner parent scope %s", node.Name, pos)
-					printer.Info(node.Pos(), i)
-					break
-				}
+// crossFileOrder numbers the files of the package by base name, then full name.
+func crossFileOrder(pass *analysis.Pass) map[string]int {
+	names := make([]string, 0, len(pass.Files))
+	for _, file := range pass.Files {
+		names = append(names, pass.Fset.File(file.Pos()).Name())
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if bi, bj := filepath.Base(names[i]), filepath.Base(names[j]); bi != bj {
+			return bi < bj
+		}
+		return names[i] < names[j]
+	})
+	order := make(map[string]int, len(names))
+	for i, name := range names {
+		order[name] = i
+	}
+	return order
+}
 
-				if funcDecl != nil && beforeFuncType {
-					check(node, def.Pos(), RecvType)
-					recvType = def
-					break
-				}
-				if funcDecl != nil && recvType == def {
-					// Reference to the receiver type within a method type or body.
-					break
-				}
-				check(node, def.Pos(), Type)
-
-			case *types.Builtin:
-				// Built-in functions like len, make, panic, etc.
-				printer.Info(node.Pos(), "skipping builtin "+node.Name)
-			case *types.PkgName:
-				// Package qualifier in selectors like fmt.Println.
-				printer.Info(node.Pos(), "skipping package name "+node.Name)
-			case *types.Label:
-				printer.Info(node.Pos(), "skipping label "+node.Name)
-			default:
-				printer.Info(
-					node.Pos(),
-					fmt.Sprintf("unexpected ident def type %T for %q", pass.TypesInfo.Uses[node], node.Name),
-				)
-			}
+// newPrinter builds the printer chain for the configured format and reports.
+// Duplicates are dropped first, so that they count neither in the ErrorCounter, the summary
+// nor against -max-errors, and the summary counts the errors hidden by -max-errors.
+func (o *Options) newPrinter(pass *analysis.Pass) Printer {
+	if o.Quiet {
+		return &DedupPrinter{Printer: o.countErrors(DiscardPrinter{})}
+	}
+	title := analyzerName + ": " + pass.Pkg.Path()
+	printer := o.newFormatPrinter(pass)
+	if o.MaxErrors > 0 {
+		printer = &MaxErrorsPrinter{
+			Printer: printer,
+			Writer:  o.log(),
+			Title:   title,
+			Max:     o.MaxErrors,
+			Count:   &o.shared.errors,
 		}
+	}
+	if o.Score > 0 {
+		printer = &ScorePrinter{
+			Printer: printer,
+			Writer:  o.log(),
+			Title:   title,
+			Fset:    pass.Fset,
+			BaseDir: o.baseDir(),
+			Top:     o.Score,
+			Weights: o.ScoreWeights,
+		}
+	}
+	if o.Summary {
+		summary := &SummaryPrinter{Printer: printer, Writer: o.log(), Title: title}
+		if o.colorize(o.log()) {
+			colors := o.colorPrinter(pass)
+			summary.Colors = &colors
+		}
+		printer = summary
+	}
+	return &DedupPrinter{Printer: o.countErrors(printer)}
+}
 
-		return true
-	})
+// countErrors wraps printer to count its errors in the ErrorCounter, if there is one.
+func (o *Options) countErrors(printer Printer) Printer {
+	if o.ErrorCounter == nil {
+		return printer
+	}
+	return &countingPrinter{Printer: printer, Counter: o.ErrorCounter}
+}
 
-	//nolint:nilnil // Done.
-	return nil, nil
+func (o *Options) newFormatPrinter(pass *analysis.Pass) Printer {
+	if o.Quiet {
+		return DiscardPrinter{}
+	}
+	switch o.Format {
+	case FormatJSON:
+		return o.filterPrinter(pass, &JSONPrinter{Pass: pass, Writer: o.output(), BaseDir: o.baseDir()})
+	case FormatSARIF:
+		// SARIF keeps notes itself, so only the positions of -verbose are added.
+		var fset *token.FileSet
+		if o.Verbose {
+			fset = pass.Fset
+		}
+		return FilterPrinter{
+			Min: o.SeverityFilter,
+			Printer: VerbosePrinter{
+				Verbose: true,
+				Fset:    fset,
+				BaseDir: o.baseDir(),
+				Printer: &SARIFPrinter{
+					Pass:         pass,
+					BaseDir:      o.baseDir(),
+					IncludeNotes: o.SARIFIncludeNotes,
+					run:          &o.shared.sarif,
+				},
+			},
+		}
+	case FormatGitHub:
+		// GitHub resolves annotation paths against the checkout, where the tool usually runs.
+		wd, _ := os.Getwd()
+		return o.filterPrinter(pass, &GitHubPrinter{Pass: pass, Writer: o.output(), BaseDir: cmp.Or(o.baseDir(), wd)})
+	case FormatGrouped:
+		wd, _ := os.Getwd()
+		grouped := &GroupedPrinter{Fset: pass.Fset, Writer: o.output(), BaseDir: cmp.Or(o.baseDir(), wd)}
+		if o.colorize(o.output()) {
+			colors := o.colorPrinter(pass)
+			grouped.Colors = &colors
+		}
+		return o.filterPrinter(pass, o.contextPrinter(pass, grouped))
+	case FormatText:
+	}
+
+	var printer Printer = SimplePrinter{Pass: pass}
+	// Diagnostics are printed to stderr by the analysis driver.
+	if o.colorize(os.Stderr) {
+		printer = o.colorPrinter(pass)
+	}
+	printer = o.filterPrinter(pass, o.contextPrinter(pass, printer))
+	if o.Stream {
+		return printer
+	}
+	return &SortedPrinter{Pass: pass, Printer: printer}
+}
+
+// colorPrinter returns a ColorPrinter for pass with the configured colors.
+func (o *Options) colorPrinter(pass *analysis.Pass) ColorPrinter {
+	return ColorPrinter{
+		Pass:         pass,
+		ColorError:   cmp.Or(o.ColorError, color.Red),
+		ColorWarning: cmp.Or(o.ColorWarning, color.Yellow),
+		ColorInfo:    cmp.Or(o.ColorInfo, color.Gray),
+		ColorOk:      cmp.Or(o.ColorOk, color.Green),
+	}
+}
+
+// filterPrinter drops the findings hidden by -verbose, or by -severity-filter when it is set.
+// Positions are only added to messages with -verbose.
+func (o *Options) filterPrinter(pass *analysis.Pass, printer Printer) Printer {
+	if o.SeverityFilter == "" {
+		return VerbosePrinter{Verbose: o.Verbose, Fset: pass.Fset, BaseDir: o.baseDir(), Printer: printer}
+	}
+	var fset *token.FileSet
+	if o.Verbose {
+		fset = pass.Fset
+	}
+	return FilterPrinter{
+		Min:     o.SeverityFilter,
+		Printer: VerbosePrinter{Verbose: true, Fset: fset, BaseDir: o.baseDir(), Printer: printer},
+	}
+}
+
+// contextPrinter adds the -context source lines to the errors printed by printer.
+func (o *Options) contextPrinter(pass *analysis.Pass, printer Printer) Printer {
+	if o.Context == 0 {
+		return printer
+	}
+	readFile := pass.ReadFile
+	if readFile == nil {
+		readFile = os.ReadFile
+	}
+	return &ContextPrinter{Fset: pass.Fset, ReadFile: readFile, Lines: o.Context, Printer: printer}
+}
+
+// baseDir returns the absolute -relative-paths directory, or "" when it is not set.
+func (o *Options) baseDir() string {
+	if o.RelativePaths == "" {
+		return ""
+	}
+	base, err := filepath.Abs(o.RelativePaths)
+	if err != nil {
+		return ""
+	}
+	return base
+}
+
+// colorize reports whether output to w is colored.
+func (o *Options) colorize(w io.Writer) bool {
+	if !o.Colorize {
+		return false
+	}
+	return o.colorSet || o.isFlagSet("color") || isTerminal(w)
+}
+
+// isFlagSet reports whether the named flag was set on the command line.
+func (o *Options) isFlagSet(name string) bool {
+	set := false
+	if o.flags != nil {
+		o.flags.Visit(func(f *flag.Flag) {
+			if f.Name == name {
+				set = true
+			}
+		})
+	}
+	return set
+}
+
+func (o *Options) output() io.Writer {
+	if o.Output == nil {
+		return stdout
+	}
+	return o.Output
+}
+
+func (o *Options) log() io.Writer {
+	if o.Log == nil {
+		return stderr
+	}
+	return o.Log
 }
@@ -1,21 +1,26 @@
 package refdir
 
 import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
 	"slices"
+	"strings"
 	"testing"
 
+	"github.com/ppipada/refdir/analysis/refdir/color"
+	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/analysistest"
 )
 
 func TestAnalyzer_DefaultDirs(t *testing.T) {
-	colorize = false
-	wd, err := os.Getwd()
-	if err != nil {
-		t.Fatalf("Failted to get workdir: %v", err)
-	}
-	analysistest.Run(t, filepath.Join(wd, "testdata", "analysistest"), Analyzer, "./defaultdirs/...")
+	opts := DefaultOptions()
+	opts.Colorize = false
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./defaultdirs/...")
 }
 
 func TestDefaultRefOrderIsValid(t *testing.T) {
@@ -33,3 +38,1002 @@ func TestDefaultRefOrderIsValid(t *testing.T) {
 		}
 	}
 }
+
+func TestOptionsValidate(t *testing.T) {
+	opts := DefaultOptions()
+	if err := opts.Validate(); err != nil {
+		t.Errorf("Expected the default options to be valid, got %v", err)
+	}
+	if err := (&Options{}).Validate(); err != nil {
+		t.Errorf("Expected the zero options to be valid, got %v", err)
+	}
+
+	opts.RefOrder[Func] = "sideways"
+	opts.TestRefOrder = map[RefKind]Direction{"nope": Up}
+	opts.MinDistance = -1
+	opts.Format = "xml"
+	err := opts.Validate()
+	if err == nil {
+		t.Fatal("Expected invalid options to fail")
+	}
+	for _, want := range []string{"func-dir", `kind "nope"`, "min-distance", "format"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in error %q", want, err)
+		}
+	}
+	if _, err := Check(&analysis.Pass{}, opts); err == nil {
+		t.Error("Expected Check to reject invalid options")
+	}
+
+	opts = DefaultOptions()
+	opts.Format = FormatSARIF
+	if err := opts.Validate(); err == nil || !strings.Contains(err.Error(), "sarif-out") {
+		t.Errorf("Expected -format=sarif without -sarif-out to fail, got %v", err)
+	}
+}
+
+func TestNewWithOptionsCopiesRefOrder(t *testing.T) {
+	opts := DefaultOptions()
+	a := NewWithOptions(opts)
+	if err := a.Flags.Set("func-dir", string(Up)); err != nil {
+		t.Fatalf("Failed to set flag: %v", err)
+	}
+	if opts.RefOrder[Func] != Down {
+		t.Errorf("caller options modified by flag: got %v", opts.RefOrder[Func])
+	}
+	if RefOrder[Func] != Down {
+		t.Errorf("default RefOrder modified by flag: got %v", RefOrder[Func])
+	}
+}
+
+func TestAnalyzer_MinDistance(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.MinDistance = 4
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./mindistance/...")
+}
+
+func TestAnalyzer_FieldDirs(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.RefOrder = map[RefKind]Direction{Field: Up}
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./fielddirs/...")
+}
+
+func TestAnalyzer_CrossFile(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.CrossFile = true
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./crossfile/...")
+}
+
+func TestAnalyzer_CrossFileDensity(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.ReportCrossFileDensity = true
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./density/...")
+}
+
+func TestAnalyzer_TestFileDirs(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	a := NewWithOptions(opts)
+	if err := a.Flags.Set("test-type-dir", string(Down)); err != nil {
+		t.Fatalf("Failed to set flag: %v", err)
+	}
+	analysistest.Run(t, testdataDir(t), a, "./testpolicy/...")
+}
+
+func TestAnalyzer_Explain(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	a := NewWithOptions(opts)
+	for name, value := range map[string]string{"explain": "true", "func-dir": "up", "test-func-dir": "down"} {
+		if err := a.Flags.Set(name, value); err != nil {
+			t.Fatalf("Failed to set flag %s: %v", name, err)
+		}
+	}
+	analysistest.Run(t, testdataDir(t), a, "./explain/...")
+
+	// Other formats only write the findings.
+	var out bytes.Buffer
+	opts.Format = FormatJSON
+	opts.Output = &out
+	opts.Explain = true
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./formats/...")
+	if got := strings.Count(out.String(), "\n"); got != 1 || strings.Contains(out.String(), "-dir=") {
+		t.Errorf("Expected a single JSON array without the policy, got %s", out.String())
+	}
+}
+
+func TestAnalyzerDoc(t *testing.T) {
+	opts := DefaultOptions()
+	opts.RefOrder[Field] = Either
+	a := NewWithOptions(opts)
+	if title, _, _ := strings.Cut(a.Doc, "\n"); title != "Report potential reference-to-declaration ordering issues" {
+		t.Errorf("Unexpected title %q", title)
+	}
+	for _, kind := range RefKinds {
+		want := fmt.Sprintf("\t%-10s %-7s %s\n", kind, opts.RefOrder[kind], refKindDocs[kind])
+		if !strings.Contains(a.Doc+"\n", want) {
+			t.Errorf("Doc is missing %q:\n%s", want, a.Doc)
+		}
+	}
+	if !strings.Contains(a.Doc, "\tfield      either ") {
+		t.Errorf("Doc does not follow the options:\n%s", a.Doc)
+	}
+}
+
+func TestAnalyzer_OnlyLines(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.OnlyLines = map[string][]LineRange{
+		filepath.Join(testdataDir(t), "onlylines", "onlylines.go"): {{Start: 6, End: 7}},
+	}
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./onlylines/...")
+}
+
+func TestParseLineRanges(t *testing.T) {
+	ranges := make(map[string][]LineRange)
+	if err := parseLineRanges("/src/a.go:3-9, /src/b.go:12", ranges); err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if err := parseLineRanges("/src/a.go:20-20", ranges); err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	want := map[string][]LineRange{
+		filepath.FromSlash("/src/a.go"): {{Start: 3, End: 9}, {Start: 20, End: 20}},
+		filepath.FromSlash("/src/b.go"): {{Start: 12, End: 12}},
+	}
+	if !maps.EqualFunc(ranges, want, slices.Equal[[]LineRange]) {
+		t.Errorf("Unexpected ranges %v, want %v", ranges, want)
+	}
+	for _, s := range []string{"a.go", ":3", "a.go:x", "a.go:3-x", "a.go:9-3", "a.go:0"} {
+		if err := parseLineRanges(s, ranges); err == nil {
+			t.Errorf("Expected an error for %q", s)
+		}
+	}
+}
+
+func TestParseScoreWeights(t *testing.T) {
+	got, err := parseScoreWeights("func=2, type=0.5")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if want := map[RefKind]float64{Func: 2, Type: 0.5}; !maps.Equal(got, want) {
+		t.Errorf("Unexpected weights: got %v, want %v", got, want)
+	}
+	for _, s := range []string{"func", "nope=1", "func=x", "func=-1"} {
+		if _, err := parseScoreWeights(s); err == nil {
+			t.Errorf("Expected an error for %q", s)
+		}
+	}
+}
+
+func TestAnalyzer_SuggestFixes(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.SuggestFixes = true
+	analysistest.RunWithSuggestedFixes(t, testdataDir(t), NewWithOptions(opts), "./fixes/...")
+}
+
+func TestAnalyzer_BuildTags(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.CrossFile = true
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./buildtags/...")
+}
+
+func TestAnalyzer_EitherDir(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.RefOrder = map[RefKind]Direction{Type: Either}
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./either/...")
+}
+
+func TestAnalyzer_IncludeGenerated(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.IncludeGenerated = true
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./generated/...")
+}
+
+func TestAnalyzer_ExcludeNames(t *testing.T) {
+	a := NewWithOptions(DefaultOptions())
+	for name, value := range map[string]string{"color": "false", "exclude-names": "^(must|fixture)"} {
+		if err := a.Flags.Set(name, value); err != nil {
+			t.Fatalf("Failed to set flag %s: %v", name, err)
+		}
+	}
+	analysistest.Run(t, testdataDir(t), a, "./excludenames/...")
+
+	if err := a.Flags.Set("exclude-names", "("); err == nil {
+		t.Error("Expected invalid regexp to be rejected")
+	}
+}
+
+func TestAnalyzer_VisibilityExported(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.Visibility = VisibilityExported
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./visibility/...")
+}
+
+func TestAnalyzer_AllowMutualRecursion(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.AllowMutualRecursion = true
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./mutualrecursion")
+
+	opts.Verbose = true
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./mutualrecursion/external")
+}
+
+func TestAnalyzer_VarInitCycles(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.VarInitCycles = true
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./varinitcycles/...")
+}
+
+func TestAnalyzer_AliasMode(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./aliases/alias/...")
+
+	opts.AliasMode = AliasModeTarget
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./aliases/target/...")
+}
+
+func TestAnalyzer_LabelDir(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.RefOrder = map[RefKind]Direction{Label: Down}
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./labels/...")
+}
+
+func TestAnalyzer_FileOverride(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./fileoverride/...")
+}
+
+func TestAnalyzer_Adjacency(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.Adjacency = 1
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./adjacency/...")
+}
+
+func TestAnalyzer_RecvFieldsAsType(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.RecvFieldsAsType = true
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./recvfields/...")
+}
+
+func TestAnalyzer_LocalDecls(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.LocalDecls = true
+	opts.RefOrder = map[RefKind]Direction{Type: Down, Var: Down, Const: Down}
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./localdecls/...")
+}
+
+func TestAnalyzer_ReportFuncViolations(t *testing.T) {
+	var log bytes.Buffer
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.ReportFuncViolations = true
+	opts.RefOrder = map[RefKind]Direction{Func: Up}
+	opts.Log = &log
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./funcviolations/...")
+
+	// File names and package paths depend on the test directory.
+	lines := strings.Split(strings.TrimSuffix(log.String(), "\n"), "\n")
+	want := []struct{ prefix, suffix string }{
+		{"refdir: ", "/funcviolations: func ordering errors per func:"},
+		{"  3 ", "funcviolations.go:9:6: busy"},
+		{"  1 ", "funcviolations.go:5:13: (*T).Set"},
+		{"  1 ", "funcviolations.go:15:6: calm"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("Unexpected report:\n%s", log.String())
+	}
+	for i, line := range lines {
+		if !strings.HasPrefix(line, want[i].prefix) || !strings.HasSuffix(line, want[i].suffix) {
+			t.Errorf("Unexpected line %q, want %q...%q", line, want[i].prefix, want[i].suffix)
+		}
+	}
+}
+
+func TestAnalyzer_IgnoreClosures(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.RefOrder = map[RefKind]Direction{Func: Up}
+	opts.IgnoreClosures = true
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./closures/...")
+}
+
+func TestAnalyzer_PkgDir(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.RefOrder = map[RefKind]Direction{Pkg: Down}
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./pkgnames/...")
+}
+
+func TestAnalyzer_GenericInstantiations(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./generics/...")
+}
+
+func TestAnalyzer_GroupTolerance(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.GroupTolerance = true
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./groups/...")
+}
+
+func TestAnalyzer_StrictIota(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./iota/lenient/...")
+
+	opts.StrictIota = true
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./iota/strict/...")
+}
+
+func TestAnalyzer_ConfigFile(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./config/fileonly/...")
+
+	a := NewWithOptions(opts)
+	if err := a.Flags.Set("func-dir", string(Down)); err != nil {
+		t.Fatalf("Failed to set flag: %v", err)
+	}
+	analysistest.Run(t, testdataDir(t), a, "./config/flagoverride/...")
+}
+
+func TestDumpConfigRoundTrip(t *testing.T) {
+	for _, name := range []string{".refdir.yaml", ".refdir.json"} {
+		opts := DefaultOptions()
+		opts.RefOrder = map[RefKind]Direction{Func: Up, Type: Either}
+		opts.TestRefOrder = map[RefKind]Direction{Func: Down}
+		opts.Verbose = true
+		opts.MinDistance = 3
+		opts.CrossFileDensityThreshold = 0.25
+		opts.SpreadThreshold = 10
+		opts.Adjacency = 2
+		opts.ExcludePaths = []string{"vendor/*", "gen"}
+		opts.IgnoreNames = map[RefKind][]string{Func: {"legacy", "T.old"}}
+		opts.init()
+
+		path := filepath.Join(t.TempDir(), name)
+		if err := opts.dumpConfig(path); err != nil {
+			t.Fatalf("%s: failed to dump config: %v", name, err)
+		}
+		cfg, err := readConfig(path)
+		if err != nil {
+			t.Fatalf("%s: failed to read dumped config: %v", name, err)
+		}
+		loaded := DefaultOptions()
+		loaded.init()
+		got := loaded.applyConfig(path, cfg)
+
+		if !maps.Equal(got.RefOrder, opts.RefOrder) {
+			t.Errorf("%s: RefOrder = %v, want %v", name, got.RefOrder, opts.RefOrder)
+		}
+		if !maps.Equal(got.TestRefOrder, opts.TestRefOrder) {
+			t.Errorf("%s: TestRefOrder = %v, want %v", name, got.TestRefOrder, opts.TestRefOrder)
+		}
+		if got.Verbose != opts.Verbose || got.MinDistance != opts.MinDistance ||
+			got.CrossFileDensityThreshold != opts.CrossFileDensityThreshold ||
+			got.SpreadThreshold != opts.SpreadThreshold || got.Adjacency != opts.Adjacency {
+			t.Errorf("%s: got %+v, want %+v", name, got, opts)
+		}
+		if got.colorSet {
+			t.Errorf("%s: color was dumped without being set", name)
+		}
+		if !slices.Equal(got.ExcludePaths, opts.ExcludePaths) {
+			t.Errorf("%s: ExcludePaths = %q, want %q", name, got.ExcludePaths, opts.ExcludePaths)
+		}
+		if names := slices.Sorted(maps.Keys(got.ignoreNames[Func])); !slices.Equal(names, []string{"T.old", "legacy"}) {
+			t.Errorf("%s: ignored funcs = %q, want [T.old legacy]", name, names)
+		}
+	}
+}
+
+func TestAnalyzer_ConfigDump(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "refdir.yaml")
+	a := NewWithOptions(DefaultOptions())
+	for flagName, value := range map[string]string{"config-dump": path, "func-dir": "up", "min-distance": "2"} {
+		if err := a.Flags.Set(flagName, value); err != nil {
+			t.Fatalf("Failed to set flag %s: %v", flagName, err)
+		}
+	}
+	// No package is checked, so the errors wanted by the testdata are not reported.
+	analysistest.Run(t, testdataDir(t), a, "./configdump/...")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read the dumped config: %v", err)
+	}
+	for _, want := range []string{"func: up\n", "min-distance: 2\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Dumped config lacks %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestAnalyzer_IgnoreList(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.IgnoreNames = map[RefKind][]string{Func: {"fromOptions"}}
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./ignorelist/...")
+}
+
+func TestAnalyzer_ExcludePaths(t *testing.T) {
+	a := NewWithOptions(DefaultOptions())
+	for flagName, value := range map[string]string{"color": "false", "verbose": "true", "exclude-paths": "vendor, excludepaths/sk*"} {
+		if err := a.Flags.Set(flagName, value); err != nil {
+			t.Fatalf("Failed to set flag %s: %v", flagName, err)
+		}
+	}
+	analysistest.Run(t, testdataDir(t), a, "./excludepaths/skipped/...")
+
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.ExcludePaths = []string{"excludepaths/sk*"}
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./excludepaths/checked/...")
+
+	if err := a.Flags.Set("exclude-paths", "[bad"); err == nil {
+		t.Error("Expected an invalid pattern to be rejected")
+	}
+}
+
+func TestAnalyzer_GraphOut(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.GraphOut = filepath.Join(t.TempDir(), "refdir.dot")
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./graph/...")
+
+	data, err := os.ReadFile(opts.GraphOut)
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+	// Packages loaded from testdata have paths like _/abs/path/testdata/analysistest/graph.
+	pkg := "_" + filepath.ToSlash(filepath.Join(testdataDir(t), "graph"))
+	for _, want := range []string{
+		`graph.Item" [label="Item"];`,
+		`graph.limit" [label="limit"];`,
+		`graph.Run" -> "` + pkg + `.limit" [color=red];`,
+		`graph.Run" -> "` + pkg + `.use" [color=green];`,
+		`graph.use" -> "` + pkg + `.Item" [color=green];`,
+		`graph.use" -> "` + pkg + `.Run" [color=red];`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected %q in graph:\n%s", want, data)
+		}
+	}
+}
+
+func TestAnalyzer_SameLine(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./sameline/ok/...")
+
+	opts.SameLine = SameLineCheck
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./sameline/check/...")
+}
+
+func TestAnalyzer_Quiet(t *testing.T) {
+	counter := &ErrorCounter{}
+	opts := DefaultOptions()
+	opts.ErrorCounter = counter
+	a := NewWithOptions(opts)
+	for name, value := range map[string]string{"quiet": "true", "verbose": "true", "summary": "true"} {
+		if err := a.Flags.Set(name, value); err != nil {
+			t.Fatalf("Failed to set flag %s: %v", name, err)
+		}
+	}
+	analysistest.Run(t, testdataDir(t), a, "./quiet/...")
+	if got := counter.Count(); got != 2 {
+		t.Errorf("Expected 2 counted errors, got %d", got)
+	}
+}
+
+func TestErrorCounterSkipsDuplicates(t *testing.T) {
+	counter := &ErrorCounter{}
+	opts := DefaultOptions()
+	opts.Quiet = true
+	opts.ErrorCounter = counter
+	printer := opts.newPrinter(&analysis.Pass{})
+	printer.Error(Finding{Pos: 1, Message: "a"})
+	printer.Error(Finding{Pos: 1, Message: "a"})
+	printer.Warn(Finding{Pos: 2, Message: "b"})
+	printer.Flush()
+	if got := counter.Count(); got != 1 {
+		t.Errorf("Expected 1 counted error, got %d", got)
+	}
+}
+
+func TestAnalyzer_Stream(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.RefOrder[RecvType] = Ignore
+	opts.MethodsAfterType = true
+	a := NewWithOptions(opts)
+	if err := a.Flags.Set("stream", "true"); err != nil {
+		t.Fatalf("Failed to set flag: %v", err)
+	}
+	results := analysistest.Run(t, testdataDir(t), a, "./stream/...")
+
+	// Findings are reported in the order they are found, not sorted by position.
+	var got []int
+	for _, r := range results {
+		for _, d := range r.Diagnostics {
+			got = append(got, r.Pass.Fset.Position(d.Pos).Line)
+		}
+	}
+	if want := []int{13, 6}; !slices.Equal(got, want) {
+		t.Errorf("Expected diagnostics on lines %v, got %v", want, got)
+	}
+}
+
+func TestAnalyzer_ErrorMethod(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.Verbose = true
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./errormethod/...")
+}
+
+func TestAnalyzer_NearMiss(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.Verbose = true
+	opts.NearMiss = true
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./nearmiss/...")
+}
+
+func TestAnalyzer_SpreadThreshold(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.Verbose = true
+	opts.SpreadThreshold = 2
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./spread/...")
+}
+
+func TestAnalyzer_ReportUnreferenced(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.Verbose = true
+	opts.ReportUnreferenced = true
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./unreferenced/unexported")
+	opts.IncludeExportedUnreferenced = true
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./unreferenced/exported")
+}
+
+func TestAnalyzer_WarnShadowing(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.Verbose = true
+	opts.WarnShadowing = true
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./shadowing/...")
+}
+
+func TestAnalyzer_MethodsAfterType(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.MethodsAfterType = true
+	// The check does not depend on the direction of receiver type references.
+	opts.RefOrder[RecvType] = Ignore
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./methodsaftertype/...")
+
+	// Its errors take the severity of receiver type references.
+	counter := &ErrorCounter{}
+	opts.ErrorCounter = counter
+	opts.RefSeverity = map[RefKind]Severity{RecvType: SeverityWarning}
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./methodsaftertype/...")
+	if got := counter.Count(); got != 0 {
+		t.Errorf("Expected no counted errors with warnings, got %d", got)
+	}
+}
+
+func TestAnalyzer_MethodsGrouped(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.MethodsGrouped = true
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./methodsgrouped/...")
+
+	// Its errors take the severity of receiver type references.
+	counter := &ErrorCounter{}
+	opts.ErrorCounter = counter
+	opts.RefSeverity = map[RefKind]Severity{RecvType: SeverityWarning}
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./methodsgrouped/...")
+	if got := counter.Count(); got != 0 {
+		t.Errorf("Expected no counted errors with warnings, got %d", got)
+	}
+}
+
+func TestAnalyzer_ConstructorAfterType(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.ConstructorAfterType = true
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./constructors")
+
+	// Its errors take the severity of type references.
+	counter := &ErrorCounter{}
+	warnings := opts
+	warnings.ErrorCounter = counter
+	warnings.RefSeverity = map[RefKind]Severity{Type: SeverityWarning}
+	analysistest.Run(t, testdataDir(t), NewWithOptions(warnings), "./constructors")
+	if got := counter.Count(); got != 0 {
+		t.Errorf("Expected no counted errors with warnings, got %d", got)
+	}
+
+	opts.ConstructorPrefix = "Make"
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./constructors/prefix")
+}
+
+func TestAnalyzer_FuncPositions(t *testing.T) {
+	a := NewWithOptions(DefaultOptions())
+	for flagName, value := range map[string]string{"color": "false", "init-position": "first", "main-position": "first"} {
+		if err := a.Flags.Set(flagName, value); err != nil {
+			t.Fatalf("Failed to set flag %s: %v", flagName, err)
+		}
+	}
+	analysistest.Run(t, testdataDir(t), a, "./funcpositions/first")
+
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.InitPosition = FuncPositionAfterVars
+	opts.MainPosition = FuncPositionAfterVars
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./funcpositions/aftervars")
+
+	// Their errors take the severity of func references.
+	counter := &ErrorCounter{}
+	opts.ErrorCounter = counter
+	opts.RefSeverity = map[RefKind]Severity{Func: SeverityWarning}
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./funcpositions/aftervars")
+	if got := counter.Count(); got != 0 {
+		t.Errorf("Expected no counted errors with warnings, got %d", got)
+	}
+
+	if err := a.Flags.Set("init-position", "last"); err == nil {
+		t.Error("Expected an invalid position to be rejected")
+	}
+}
+
+func TestFactsAnalyzer(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	analysistest.Run(t, testdataDir(t), NewFactsAnalyzer(opts), "./facts/...")
+}
+
+func TestAnalyzer_FirstUseOnly(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.FirstUseOnly = true
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./firstuse/...")
+}
+
+func TestAnalyzer_RespectNolint(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./nolint/respect/...")
+
+	opts.RespectNolint = false
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./nolint/disabled/...")
+}
+
+func TestColorizeOnlyTerminalsByDefault(t *testing.T) {
+	opts := DefaultOptions()
+	opts.flags = flag.NewFlagSet("refdir", flag.ContinueOnError)
+	opts.registerFlags(opts.flags)
+	if opts.colorize(&bytes.Buffer{}) {
+		t.Error("Expected no colors when not writing to a terminal")
+	}
+	if err := opts.flags.Set("color", "true"); err != nil {
+		t.Fatalf("Failed to set flag: %v", err)
+	}
+	if !opts.colorize(&bytes.Buffer{}) {
+		t.Error("Expected colors when set explicitly")
+	}
+}
+
+func TestColorFlags(t *testing.T) {
+	opts := DefaultOptions()
+	fs := flag.NewFlagSet("refdir", flag.ContinueOnError)
+	opts.registerFlags(fs)
+	if err := fs.Set("color-info", "blue"); err != nil {
+		t.Fatalf("Failed to set flag: %v", err)
+	}
+	if err := fs.Set("color-ok", "208"); err != nil {
+		t.Fatalf("Failed to set flag: %v", err)
+	}
+	if opts.ColorInfo != color.Blue || opts.ColorOk == "" || opts.ColorError != "" {
+		t.Errorf("Unexpected colors: info %q, ok %q, error %q", opts.ColorInfo, opts.ColorOk, opts.ColorError)
+	}
+	for _, s := range []string{"mauve", "256", "-1", "07"} {
+		if err := fs.Set("color-error", s); err == nil {
+			t.Errorf("Expected an error for color %q", s)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	var findings []Finding
+	a := &analysis.Analyzer{
+		Name: "checktest",
+		Doc:  "Collect the findings of Check",
+		Run: func(pass *analysis.Pass) (any, error) {
+			got, err := Check(pass, Options{})
+			findings = append(findings, got...)
+			//nolint:nilnil // Done.
+			return nil, err
+		},
+	}
+	analysistest.Run(t, testdataDir(t), a, "./formats/...")
+
+	var errs, oks []Finding
+	for _, f := range findings {
+		switch f.Severity {
+		case SeverityError:
+			errs = append(errs, f)
+		case SeverityOk:
+			oks = append(oks, f)
+		case SeverityInfo:
+		}
+	}
+	if len(errs) != 1 || errs[0].Kind != Type || errs[0].Name != "LaterType" || errs[0].Direction != Up {
+		t.Fatalf("Expected a single type error for LaterType, got %+v", errs)
+	}
+	if !errs[0].DefPos.IsValid() || errs[0].DefPos <= errs[0].Pos {
+		t.Errorf("Expected the definition after the reference, got ref %v def %v", errs[0].Pos, errs[0].DefPos)
+	}
+	if len(oks) != 1 || oks[0].Kind != Func || oks[0].Name != "laterFunc" || oks[0].Direction != Down {
+		t.Errorf("Expected a single ok func reference to laterFunc, got %+v", oks)
+	}
+}
+
+func TestAnalyzer_DotImports(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Colorize = false
+	opts.RefOrder = map[RefKind]Direction{Func: Up, Type: Down}
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./dotimports/...")
+
+	var infos []string
+	a := &analysis.Analyzer{
+		Name: "dotimports",
+		Doc:  "Collect the info findings of Check",
+		Run: func(pass *analysis.Pass) (any, error) {
+			findings, err := Check(pass, opts)
+			for _, f := range findings {
+				if f.Severity == SeverityInfo {
+					infos = append(infos, f.Message)
+				}
+			}
+			//nolint:nilnil // Done.
+			return nil, err
+		},
+	}
+	analysistest.Run(t, testdataDir(t), a, "./dotimports/...")
+	for _, want := range []string{
+		"type reference Builder is to definition in package strings",
+		"func reference TrimSpace is to definition in package strings",
+	} {
+		if !slices.Contains(infos, want) {
+			t.Errorf("Expected info %q, got %q", want, infos)
+		}
+	}
+}
+
+func TestAnalyzer_JSONFormat(t *testing.T) {
+	var out bytes.Buffer
+	opts := DefaultOptions()
+	opts.Format = FormatJSON
+	opts.Output = &out
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./formats/...")
+
+	var findings []map[string]any
+	if err := json.Unmarshal(out.Bytes(), &findings); err != nil {
+		t.Fatalf("Failed to decode JSON output %q: %v", out.String(), err)
+	}
+	if len(findings) != 1 {
+		t.Fatalf("Expected 1 finding, got %d: %s", len(findings), out.String())
+	}
+	want := map[string]any{
+		"file":     "formats.go",
+		"line":     4.0,
+		"column":   6.0,
+		"kind":     "type",
+		"refName":  "LaterType",
+		"severity": "error",
+		"message":  "type reference LaterType is before definition (policy: references should appear after, i.e. up)",
+	}
+	got := findings[0]
+	file, _ := got["file"].(string)
+	got["file"] = filepath.Base(file)
+	if !maps.Equal(got, want) {
+		t.Errorf("Unexpected finding:\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestAnalyzer_RelativePaths(t *testing.T) {
+	var out bytes.Buffer
+	opts := DefaultOptions()
+	opts.Format = FormatJSON
+	opts.Verbose = true
+	opts.Output = &out
+	opts.RelativePaths = testdataDir(t)
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./formats/...")
+
+	var findings []map[string]any
+	if err := json.Unmarshal(out.Bytes(), &findings); err != nil {
+		t.Fatalf("Failed to decode JSON output %q: %v", out.String(), err)
+	}
+	var errs []map[string]any
+	for _, f := range findings {
+		if f["severity"] == "error" {
+			errs = append(errs, f)
+		}
+	}
+	if len(errs) != 1 {
+		t.Fatalf("Expected 1 error, got %d: %s", len(errs), out.String())
+	}
+	file, _ := errs[0]["file"].(string)
+	message, _ := errs[0]["message"].(string)
+	wantFile := filepath.Join("formats", "formats.go")
+	wantMessage := "ref@" + wantFile + ":4:6 -> def@" + wantFile + ":8:6: " +
+		"type reference LaterType is before definition ("
+	if file != wantFile || !strings.HasPrefix(message, wantMessage) {
+		t.Errorf("Unexpected file %q and message %q, want %q and %q...", file, message, wantFile, wantMessage)
+	}
+}
+
+func TestRelativePathsFlag(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{args: nil, want: ""},
+		{args: []string{"-relative-paths"}, want: "."},
+		{args: []string{"-relative-paths=/src"}, want: "/src"},
+		{args: []string{"-relative-paths", "-relative-paths=false"}, want: ""},
+	} {
+		opts := DefaultOptions()
+		fs := flag.NewFlagSet("refdir", flag.ContinueOnError)
+		opts.registerFlags(fs)
+		if err := fs.Parse(tc.args); err != nil {
+			t.Fatalf("Failed to parse %q: %v", tc.args, err)
+		}
+		if opts.RelativePaths != tc.want {
+			t.Errorf("%q: RelativePaths = %q, want %q", tc.args, opts.RelativePaths, tc.want)
+		}
+	}
+}
+
+func TestAnalyzer_GitHubFormat(t *testing.T) {
+	var out bytes.Buffer
+	opts := DefaultOptions()
+	opts.Format = FormatGitHub
+	opts.Output = &out
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./formats/...")
+
+	got := out.String()
+	wantPrefix := "::error file=testdata/"
+	wantSuffix := "/formats/formats.go,line=4,col=6,title=refdir type::" +
+		"type reference LaterType is before definition (policy: references should appear after, i.e. up)\n"
+	if !strings.HasPrefix(got, wantPrefix) || !strings.HasSuffix(got, wantSuffix) || strings.Count(got, "\n") != 1 {
+		t.Errorf("Unexpected output %q, want %q...%q", got, wantPrefix, wantSuffix)
+	}
+}
+
+func TestAnalyzer_Severity(t *testing.T) {
+	var out bytes.Buffer
+	opts := DefaultOptions()
+	opts.Format = FormatJSON
+	opts.Output = &out
+	a := NewWithOptions(opts)
+	if err := a.Flags.Set("type-severity", string(SeverityWarning)); err != nil {
+		t.Fatalf("Failed to set flag: %v", err)
+	}
+	analysistest.Run(t, testdataDir(t), a, "./formats/...")
+
+	var findings []map[string]any
+	if err := json.Unmarshal(out.Bytes(), &findings); err != nil {
+		t.Fatalf("Failed to decode JSON output %q: %v", out.String(), err)
+	}
+	if len(findings) != 1 || findings[0]["severity"] != "warning" {
+		t.Errorf("Expected a single warning, got %s", out.String())
+	}
+}
+
+func TestAnalyzer_SARIFFormat(t *testing.T) {
+	for _, includeNotes := range []bool{false, true} {
+		path := filepath.Join(t.TempDir(), "refdir.sarif")
+		opts := DefaultOptions()
+		opts.Format = FormatSARIF
+		opts.SARIFOut = path
+		opts.SARIFIncludeNotes = includeNotes
+		// The findings of both packages end up in a single run.
+		analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./formats/...", "./quiet/...")
+
+		out, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read SARIF log: %v", err)
+		}
+		var log sarifLog
+		if err := json.Unmarshal(out, &log); err != nil {
+			t.Fatalf("Failed to decode SARIF log %q: %v", out, err)
+		}
+		if log.Version != sarifVersion || len(log.Runs) != 1 {
+			t.Fatalf("Unexpected SARIF log: %s", out)
+		}
+		var errs, notes []sarifResult
+		for _, r := range log.Runs[0].Results {
+			if r.Level == "error" {
+				errs = append(errs, r)
+			} else {
+				notes = append(notes, r)
+			}
+		}
+		if len(errs) != 3 {
+			t.Fatalf("Expected 3 error results, got %d: %s", len(errs), out)
+		}
+		region := errs[0].Locations[0].PhysicalLocation.Region
+		if errs[0].RuleID != "refdir/type" || region.StartLine != 4 || region.StartColumn != 6 {
+			t.Errorf("Unexpected error result %+v", errs[0])
+		}
+		if includeNotes == (len(notes) == 0) {
+			t.Errorf("With include notes %v got %d note results", includeNotes, len(notes))
+		}
+	}
+}
+
+func TestAnalyzer_Baseline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "refdir-baseline.json")
+	runJSON := func(opts Options) string {
+		t.Helper()
+		var out bytes.Buffer
+		opts.Format = FormatJSON
+		opts.Output = &out
+		analysistest.Run(t, testdataDir(t), NewWithOptions(opts), "./formats/...")
+		return out.String()
+	}
+
+	opts := DefaultOptions()
+	opts.BaselineOut = path
+	if out := runJSON(opts); out == "" {
+		t.Fatal("Expected an error while writing the baseline")
+	}
+	baseline, err := LoadBaseline(path)
+	if err != nil {
+		t.Fatalf("Failed to load baseline: %v", err)
+	}
+	want := BaselineEntry{
+		File:    filepath.Join(testdataDir(t), "formats", "formats.go"),
+		Kind:    Type,
+		RefName: "LaterType",
+	}
+	if len(baseline) != 1 || !baseline[want] {
+		t.Fatalf("Unexpected baseline entries %v", baseline)
+	}
+
+	opts = DefaultOptions()
+	opts.Baseline = baseline
+	if out := runJSON(opts); out != "" {
+		t.Errorf("Expected baseline to suppress all errors, got %s", out)
+	}
+}
+
+func testdataDir(t *testing.T) string {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get workdir: %v", err)
+	}
+	return filepath.Join(wd, "testdata", "analysistest")
+}
@@ -0,0 +1,92 @@
+package refdir
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// A BaselineEntry identifies a grandfathered ordering error. It has no line so that it
+// survives unrelated edits to the file.
+type BaselineEntry struct {
+	File    string  `json:"file"`
+	Kind    RefKind `json:"kind"`
+	RefName string  `json:"refName"`
+}
+
+// baselineFile is the serialized form of a baseline. File names are relative to the
+// directory of the baseline file.
+type baselineFile struct {
+	Entries []BaselineEntry `json:"entries"`
+}
+
+// baselineWriter accumulates the errors of all packages of a run and rewrites the
+// baseline file with the full set each time a package is added.
+type baselineWriter struct {
+	mu      sync.Mutex
+	entries map[BaselineEntry]bool
+}
+
+func (w *baselineWriter) add(path string, entries []BaselineEntry) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.entries == nil {
+		w.entries = make(map[BaselineEntry]bool)
+	}
+	for _, e := range entries {
+		w.entries[e] = true
+	}
+	return writeBaseline(path, slices.Collect(maps.Keys(w.entries)))
+}
+
+// LoadBaseline reads a baseline written with -baseline-out.
+// The returned entries have absolute file names.
+func LoadBaseline(path string) (map[BaselineEntry]bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file baselineFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid baseline %s: %w", path, err)
+	}
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	entries := make(map[BaselineEntry]bool, len(file.Entries))
+	for _, e := range file.Entries {
+		e.File = filepath.Join(dir, filepath.FromSlash(e.File))
+		entries[e] = true
+	}
+	return entries, nil
+}
+
+// writeBaseline writes entries with absolute file names to path, sorted and with file
+// names relative to the directory of path.
+func writeBaseline(path string, entries []BaselineEntry) error {
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	file := baselineFile{Entries: make([]BaselineEntry, 0, len(entries))}
+	for _, e := range entries {
+		if rel, err := filepath.Rel(dir, e.File); err == nil {
+			e.File = filepath.ToSlash(rel)
+		}
+		file.Entries = append(file.Entries, e)
+	}
+	slices.SortFunc(file.Entries, func(a, b BaselineEntry) int {
+		return strings.Compare(a.File+"\x00"+string(a.Kind)+"\x00"+a.RefName, b.File+"\x00"+string(b.Kind)+"\x00"+b.RefName)
+	})
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
@@ -0,0 +1,1031 @@
+package refdir
+
+import (
+	"cmp"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"maps"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// errorType is the predeclared error interface. Neither it nor its Error method have a
+// source position.
+var errorType = types.Universe.Lookup("error")
+
+// funcViolation is the number of func ordering errors in the body of a func declaration.
+type funcViolation struct {
+	pos   token.Pos
+	name  string
+	count int
+}
+
+// defKey identifies the definition of a kind of reference, for -first-use-only.
+type defKey struct {
+	pos  token.Pos
+	kind RefKind
+}
+
+// checkResult is the outcome of checking a single package.
+type checkResult struct {
+	findings []Finding
+	// Errors to write to the -baseline-out file.
+	baselineOut []BaselineEntry
+	// Funcs with func ordering errors in their body, most first, for -report-func-violations.
+	funcViolations []funcViolation
+}
+
+// collector records findings, setting the severity from the method they are passed to.
+// Findings are kept only with keep, and passed to stream as they are recorded if it is set.
+type collector struct {
+	findings []Finding
+	stream   func(Finding)
+	keep     bool
+}
+
+// check collects the findings of a single package. If stream is not nil, it receives each
+// finding as soon as it is found, for -stream, and the result only holds the findings
+// -graph-out needs.
+func (o *Options) check(pass *analysis.Pass, stream func(Finding)) checkResult {
+	printer := &collector{stream: stream, keep: stream == nil || o.GraphOut != ""}
+
+	analysisInspector, ok := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	if !ok {
+		analysisInspector = inspector.New(pass.Files)
+	}
+
+	// Positions in messages, relative to the -relative-paths base when it is set.
+	base := o.baseDir()
+	position := func(pos token.Pos) string {
+		p := pass.Fset.Position(pos)
+		p.Filename = relativePath(base, p)
+		return p.String()
+	}
+
+	// The position of a definition at the end of messages, left out with -verbose, where
+	// VerbosePrinter prefixes messages with it.
+	defSuffix := func(pos token.Pos) string {
+		if o.Verbose {
+			return ""
+		}
+		return " (" + position(pos) + ")"
+	}
+
+	// Errors to write to the -baseline-out file.
+	var baselineOut []BaselineEntry
+
+	// The //refdir:ignore directives of each file, keyed by file name and then line.
+	ignores := make(map[string]map[int]ignoreDirective)
+
+	// The lines covered by //nolint directives in each file, keyed by file name, for
+	// -respect-nolint.
+	nolint := make(map[string]map[int]bool)
+
+	// The declaration groups of each file, keyed by file name, for -group-tolerance.
+	groups := make(map[string][]lineSpan)
+
+	// Position of each package file in the -cross-file order.
+	fileIndex := crossFileOrder(pass)
+
+	// The files of the package in the current build configuration, keyed by name.
+	files := make(map[string]*ast.File, len(pass.Files))
+	for _, file := range pass.Files {
+		files[pass.Fset.File(file.Pos()).Name()] = file
+	}
+
+	// Checked references to definitions in the package, and those in another file, for
+	// -report-cross-file-density.
+	var pkgRefs, crossFileRefs int
+
+	// Directions for the current file, with its //refdir:kind=dir overrides applied.
+	refOrder := o.RefOrder
+
+	// State for keeping track of the receiver type.
+	// No need for a stack as method declarations can only be at file scope.
+	var (
+		funcDecl       *ast.FuncDecl
+		recvType       *types.TypeName
+		beforeFuncType bool
+	)
+
+	// Funcs referenced from a single declaration, for -adjacency.
+	var singleCaller map[*types.Func]bool
+	if o.Adjacency > 0 {
+		singleCaller = singleCallerFuncs(pass)
+	}
+
+	// Func ordering errors in the body of each func declaration, for -report-func-violations.
+	funcViolations := make(map[*ast.FuncDecl]int)
+
+	// Position of the first reported reference to each definition, for -first-use-only.
+	firstUses := make(map[defKey]token.Pos)
+
+	// Package-scope declarations referenced from each func declaration, for -spread-threshold.
+	spread := make(map[*ast.FuncDecl]map[types.Object]bool)
+
+	// Objects referenced in the package, for -report-unreferenced and -warn-shadowing.
+	referenced := make(map[types.Object]bool)
+
+	// Used local declarations shadowing a package-scope declaration, for -warn-shadowing.
+	shadows := make(map[types.Object]types.Object)
+	noteShadowing := func(local types.Object) {
+		if outer := pass.Pkg.Scope().Lookup(local.Name()); o.WarnShadowing && outer != nil {
+			shadows[local] = outer
+		}
+	}
+
+	// suppressed reports whether an ordering error is suppressed by a //refdir:ignore or
+	// //nolint directive, the baseline or -only-lines, printing it as info if so.
+	suppressed := func(f Finding) bool {
+		refFile, refLine := pass.Fset.File(f.Pos).Name(), pass.Fset.Position(f.Pos).Line
+		ignored := ignores[refFile][refLine].covers(f.Kind)
+		if defFile := pass.Fset.File(f.DefPos); defFile != nil {
+			ignored = ignored || ignores[defFile.Name()][pass.Fset.Position(f.DefPos).Line].covers(f.Kind)
+		}
+		if ignored {
+			f.Message += " (suppressed by " + ignoreDirectivePrefix + ")"
+			printer.Info(f)
+			return true
+		}
+
+		if nolint[refFile][refLine] {
+			f.Message += " (suppressed by " + nolintDirectivePrefix + ")"
+			printer.Info(f)
+			return true
+		}
+
+		entry := BaselineEntry{File: refFile, Kind: f.Kind, RefName: f.Name}
+		if o.BaselineOut != "" {
+			baselineOut = append(baselineOut, entry)
+		}
+		if o.Baseline[entry] {
+			f.Message += " (in baseline)"
+			printer.Info(f)
+			return true
+		}
+
+		if o.OnlyLines != nil && !inLineRanges(o.OnlyLines[refFile], refLine) {
+			f.Message += " (outside the lines of -only-lines)"
+			printer.Info(f)
+			return true
+		}
+		return false
+	}
+
+	// report prints an ordering error with the severity of its kind.
+	report := func(f Finding) {
+		switch o.RefSeverity[f.Kind] {
+		case SeverityWarning:
+			printer.Warn(f)
+		case SeverityInfo:
+			printer.Info(f)
+		default:
+			printer.Error(f)
+		}
+	}
+
+	check := func(ref *ast.Ident, def types.Object, kind RefKind) {
+		defPos := def.Pos()
+		f := Finding{Pos: ref.Pos(), DefPos: defPos, Kind: kind, Direction: refOrder[kind], Name: ref.Name}
+		if isErrorMethod(def) {
+			// err.Error() is an interface method selection, checked against the error
+			// interface, which is predeclared.
+			f.Message = "skipping error.Error() builtin method"
+			printer.Info(f)
+			return
+		}
+		if !defPos.IsValid() || pass.Fset.File(defPos) == nil {
+			// Possible for cgo, assembly and other declarations without Go source in the
+			// file set.
+			f.Message = fmt.Sprintf(
+				"%s reference %s: definition has no Go source position; likely cgo/asm/builtin",
+				kind,
+				ref.Name,
+			)
+			printer.Info(f)
+			return
+		}
+
+		if pass.Fset.File(ref.Pos()) == nil {
+			// Synthesized by another pass, for instance.
+			f.Message = fmt.Sprintf("%s reference %s: cannot determine file for position", kind, ref.Name)
+			printer.Info(f)
+			return
+		}
+
+		if def.Pkg() != pass.Pkg {
+			// Definitions in other packages, including names brought in by dot imports, have no
+			// order relative to the reference.
+			pkg := "the universe"
+			if def.Pkg() != nil {
+				pkg = "package " + def.Pkg().Path()
+			}
+			f.Message = fmt.Sprintf("%s reference %s is to definition in %s", kind, ref.Name, pkg)
+			printer.Info(f)
+			return
+		}
+
+		if o.SpreadThreshold > 0 && funcDecl != nil {
+			if obj := packageScopeObject(pass.Pkg, def); obj != nil && obj != pass.TypesInfo.Defs[funcDecl.Name] {
+				if spread[funcDecl] == nil {
+					spread[funcDecl] = make(map[types.Object]bool)
+				}
+				spread[funcDecl][obj] = true
+			}
+		}
+
+		if refOrder[kind] == Ignore {
+			f.Message = fmt.Sprintf("%s reference %s ignored by options", kind, ref.Name)
+			printer.Info(f)
+			return
+		}
+
+		if exported := def.Exported(); (o.Visibility == VisibilityExported && !exported) ||
+			(o.Visibility == VisibilityUnexported && exported) {
+			f.Message = fmt.Sprintf("%s reference %s skipped by visibility %s", kind, ref.Name, o.Visibility)
+			printer.Info(f)
+			return
+		}
+
+		if o.ExcludeNames != nil && o.ExcludeNames.MatchString(ref.Name) {
+			f.Message = fmt.Sprintf("%s reference %s excluded by name", kind, ref.Name)
+			printer.Info(f)
+			return
+		}
+
+		if source, ok := ignoredName(o.ignoreNames[kind], def); ok {
+			f.Message = fmt.Sprintf("%s reference %s excluded by the ignore list of %s", kind, ref.Name, source)
+			printer.Info(f)
+			return
+		}
+
+		refFile, defFile := pass.Fset.File(ref.Pos()).Name(), pass.Fset.File(defPos).Name()
+		_, defInBuild := files[defFile]
+
+		sameFile := refFile == defFile
+		pkgRefs++
+		if !sameFile {
+			crossFileRefs++
+		}
+		if !sameFile && (!o.CrossFile || !defInBuild) {
+			f.Message = fmt.Sprintf(
+				`%s reference %s is to definition in separate file%s`,
+				kind,
+				ref.Name,
+				defSuffix(defPos),
+			)
+			printer.Info(f)
+			return
+		}
+
+		// Across files, the -cross-file order of the files stands in for lines.
+		refLine, defLine := pass.Fset.Position(ref.Pos()).Line, pass.Fset.Position(defPos).Line
+		refAt, defAt := refLine, defLine
+		if !sameFile {
+			refAt, defAt = fileIndex[refFile], fileIndex[defFile]
+		}
+		result := Evaluate(refAt, defAt, refOrder[kind])
+		if result == ResultSameLine && o.SameLine == SameLineCheck {
+			// Within a line, columns stand in for lines.
+			refAt, defAt = pass.Fset.Position(ref.Pos()).Column, pass.Fset.Position(defPos).Column
+			result = Evaluate(refAt, defAt, refOrder[kind])
+		}
+		if result == ResultSameLine {
+			f.Message = fmt.Sprintf(
+				`%s reference %s is on same line as definition%s`,
+				kind,
+				ref.Name,
+				defSuffix(defPos),
+			)
+			printer.Ok(f)
+			return
+		}
+
+		order := "before"
+		if refAt > defAt {
+			order = "after"
+		}
+		switch {
+		case !sameFile:
+			f.Message = fmt.Sprintf(
+				`%s reference %s is %s definition in %s`,
+				kind,
+				ref.Name,
+				order,
+				filepath.Base(defFile),
+			)
+		default:
+			f.Message = fmt.Sprintf(`%s reference %s is %s definition`, kind, ref.Name, order)
+		}
+
+		if result == ResultOk {
+			fn, _ := def.(*types.Func)
+			distance := 0
+			if o.Adjacency > 0 && kind == Func && sameFile && singleCaller[fn] {
+				distance = declDistance(files[refFile], ref.Pos(), defPos)
+			}
+			if distance <= o.Adjacency {
+				printer.Ok(f)
+				if o.NearMiss && sameFile && (refOrder[kind] == Up || refOrder[kind] == Down) {
+					if between, ok := nearMiss(pass.TypesInfo, files[refFile], ref.Pos(), defPos); ok {
+						miss := f
+						miss.Message = fmt.Sprintf(
+							"%s reference %s is one declaration away from its definition, moving %s would make them adjacent (see -near-miss)",
+							kind,
+							ref.Name,
+							declLabel(between),
+						)
+						printer.Info(miss)
+					}
+				}
+				return
+			}
+			f.Message += fmt.Sprintf(
+				", %d declarations away (policy: funcs with a single caller should be within %d, see -adjacency)",
+				distance,
+				o.Adjacency,
+			)
+		}
+
+		if !o.StrictIota && sameFile && inSameConstBlock(files[refFile], ref.Pos(), defPos) {
+			f.Message += " (within the same const block, see -strict-iota)"
+			printer.Ok(f)
+			return
+		}
+
+		if distance := max(refLine-defLine, defLine-refLine); sameFile && distance < o.MinDistance {
+			f.Message += fmt.Sprintf(" (gap of %d lines is within min-distance %d)", distance, o.MinDistance)
+			printer.Ok(f)
+			return
+		}
+
+		if o.GroupTolerance && sameFile && sameGroup(groups[refFile], refLine, defLine) {
+			f.Message += " (within the same declaration group)"
+			printer.Ok(f)
+			return
+		}
+
+		if suppressed(f) {
+			return
+		}
+
+		if o.FirstUseOnly {
+			key := defKey{pos: defPos, kind: kind}
+			if first, ok := firstUses[key]; ok {
+				f.Message += fmt.Sprintf(" (already reported at %s, see -first-use-only)", position(first))
+				printer.Info(f)
+				return
+			}
+			firstUses[key] = ref.Pos()
+		}
+
+		if result != ResultOk {
+			expected := "before"
+			if refOrder[kind] == Up {
+				expected = "after"
+			}
+			f.Message += fmt.Sprintf(" (policy: references should appear %s, i.e. %s)", expected, refOrder[kind])
+		}
+
+		if o.SuggestFixes && sameFile {
+			f.Fixes = moveDeclFixes(pass, files[refFile], ref.Pos(), defPos, refOrder[kind] == Up)
+		}
+		if kind == Func && funcDecl != nil && o.RefSeverity[kind] != SeverityInfo {
+			funcViolations[funcDecl]++
+		}
+		report(f)
+	}
+
+	// Mutual recursion groups of the package functions, for -allow-mutual-recursion.
+	var recursion map[*types.Func]int
+	if o.AllowMutualRecursion {
+		recursion = recursionGroups(pass)
+	}
+
+	// Labels named by goto statements. Labeled break and continue statements always refer to
+	// an enclosing statement, so they have no ordering to check.
+	gotoLabels := make(map[*ast.Ident]bool)
+
+	// Field names used as keys in struct literals, including nested literals with elided types.
+	literalKeys := make(map[*ast.Ident]bool)
+
+	// Map selector identifiers (the "Sel" in x.Sel) to their selections so we can
+	// distinguish interface method selections from concrete ones.
+	// Sized up front, as every selection is recorded and map growth is noticeable on large packages.
+	selOfIdent := make(map[*ast.Ident]*types.Selection, len(pass.TypesInfo.Selections))
+
+	// Function literals enclosing the current node, for -ignore-closures.
+	var funcLits []*ast.FuncLit
+
+	// Parameters and results of function literals, which are declared in the body of the
+	// enclosing function but are not local declarations for -local-decls.
+	funcLitParams := make(map[types.Object]bool)
+
+	// With -local-decls, declarations in the body of the outermost enclosing function
+	// are checked like package declarations.
+	localDecl := func(def types.Object) bool {
+		var body *ast.BlockStmt
+		switch {
+		case !o.LocalDecls:
+			return false
+		case funcDecl != nil:
+			body = funcDecl.Body
+		case len(funcLits) > 0:
+			body = funcLits[0].Body
+		}
+		return body != nil && body.Pos() <= def.Pos() && def.Pos() < body.End() && !funcLitParams[def]
+	}
+
+	analysisInspector.Nodes(nil, func(n ast.Node, push bool) (proceed bool) {
+		if !push {
+			if funcDecl == n {
+				funcDecl = nil
+				recvType = nil
+			}
+			if len(funcLits) > 0 && funcLits[len(funcLits)-1] == n {
+				funcLits = funcLits[:len(funcLits)-1]
+			}
+			return true
+		}
+
+		switch node := n.(type) {
+		case *ast.File:
+			if ast.IsGenerated(node) && !o.IncludeGenerated {
+				printer.Info(Finding{Pos: node.Pos(), Message: "skipping generated file (see -include-generated)"})
+				if o.ReportUnreferenced {
+					// References from generated code still count.
+					for ident, obj := range pass.TypesInfo.Uses {
+						if node.Pos() <= ident.Pos() && ident.Pos() < node.End() {
+							referenced[obj] = true
+						}
+					}
+				}
+				return false
+			}
+			refOrder = o.RefOrder
+			if len(o.TestRefOrder) > 0 && strings.HasSuffix(pass.Fset.File(node.Pos()).Name(), "_test.go") {
+				refOrder = maps.Clone(o.RefOrder)
+				maps.Copy(refOrder, o.TestRefOrder)
+			}
+			if overrides := parseFileDirections(node, func(pos token.Pos, setting string) {
+				printer.Info(Finding{
+					Pos:     pos,
+					Message: fmt.Sprintf("ignoring invalid setting %q in file directive", setting),
+				})
+			}); overrides != nil {
+				refOrder = maps.Clone(refOrder)
+				maps.Copy(refOrder, overrides)
+			}
+			if o.GroupTolerance {
+				tf := pass.Fset.File(node.Pos())
+				groups[tf.Name()] = declGroups(tf, node)
+			}
+			if o.RespectNolint {
+				nolint[pass.Fset.File(node.Pos()).Name()] = parseNolintDirectives(pass.Fset, node)
+			}
+			ignores[pass.Fset.File(node.Pos()).Name()] = parseIgnoreDirectives(
+				pass.Fset,
+				node,
+				func(pos token.Pos, kind string) {
+					printer.Info(Finding{
+						Pos:     pos,
+						Message: fmt.Sprintf("unknown kind %q in %s directive", kind, ignoreDirectivePrefix),
+					})
+				},
+			)
+
+		case *ast.CompositeLit:
+			t := pass.TypesInfo.TypeOf(node)
+			if p, ok := t.(*types.Pointer); ok {
+				t = p.Elem()
+			}
+			if _, ok := t.Underlying().(*types.Struct); ok {
+				for _, elt := range node.Elts {
+					if kv, ok := elt.(*ast.KeyValueExpr); ok {
+						if key, ok := kv.Key.(*ast.Ident); ok {
+							literalKeys[key] = true
+						}
+					}
+				}
+			}
+
+		case *ast.SelectorExpr:
+			if sel := pass.TypesInfo.Selections[node]; sel != nil {
+				selOfIdent[node.Sel] = sel
+			}
+
+		case *ast.FuncDecl:
+			if funcDecl == nil {
+				funcDecl = node
+				beforeFuncType = true
+			}
+
+		case *ast.FuncType:
+			beforeFuncType = false
+
+		case *ast.FuncLit:
+			funcLits = append(funcLits, node)
+			if o.LocalDecls {
+				for _, fields := range []*ast.FieldList{node.Type.Params, node.Type.Results} {
+					for _, field := range fields.List {
+						for _, name := range field.Names {
+							funcLitParams[pass.TypesInfo.Defs[name]] = true
+						}
+					}
+				}
+			}
+
+		case *ast.BranchStmt:
+			if node.Tok == token.GOTO {
+				gotoLabels[node.Label] = true
+			}
+
+		case *ast.Ident:
+			// If this ident is a definition or otherwise has no associated use,
+			// skip it to avoid noisy "unexpected ident" messages. This includes the blank
+			// identifier, which is neither a use nor a definition: in _ = f and var _ = f,
+			// only f is a reference.
+			obj := pass.TypesInfo.Uses[node]
+			if obj == nil {
+				break
+			}
+			referenced[obj] = true
+			skip := func(message string) {
+				printer.Info(Finding{Pos: node.Pos(), Name: node.Name, Message: message})
+			}
+			if o.IgnoreClosures && len(funcLits) > 0 {
+				skip(fmt.Sprintf("skipping reference %s inside function literal (see -ignore-closures)", node.Name))
+				break
+			}
+
+			switch def := obj.(type) {
+			case *types.Var:
+				def = def.Origin()
+				switch {
+				case def.IsField() && literalKeys[node] && refOrder[Field] == Ignore:
+					// Only the type and values of a struct literal are references to order;
+					// its keys are field references.
+					skip(fmt.Sprintf("skipping field name %s in struct literal (see -field-dir)", node.Name))
+				case def.IsField() && o.RecvFieldsAsType && recvType != nil && declaresField(recvType, def):
+					// Fields of the receiver type stand for the receiver type declaration.
+					check(node, recvType, RecvType)
+				case def.IsField():
+					// Promoted fields resolve to the field in the embedded struct,
+					// so they are ordered against that declaration.
+					check(node, def, Field)
+				case def.Parent() != def.Pkg().Scope() && !localDecl(def):
+					skip(fmt.Sprintf("skipping var ident %s with inner parent scope %s", node.Name,
+						position(def.Parent().Pos())))
+					noteShadowing(def)
+				default:
+					check(node, def, Var)
+				}
+			case *types.Const:
+				if def.Parent() != def.Pkg().Scope() && !localDecl(def) {
+					pos := position(def.Parent().Pos())
+					i := fmt.Sprintf("skipping var ident %s with inner parent scope %s", node.Name, pos)
+					skip(i)
+					noteShadowing(def)
+				} else {
+					check(node, def, Const)
+				}
+
+			case *types.Func:
+				def = def.Origin()
+				// Allow direct self-recursion (call to the function we're inside).
+				if funcDecl != nil {
+					curr, ok := pass.TypesInfo.Defs[funcDecl.Name].(*types.Func)
+					if ok && curr != nil && curr.Origin() == def {
+						// For a recursive call, pass.TypesInfo.Uses[node] returns the current function’s object;
+						// comparing its Origin() to the current func’s Origin() lets us detect direct recursion even
+						// with generics instantiation.
+						break
+					}
+					// Optionally allow mutual recursion, where no order can satisfy both references.
+					group, currOk := recursion[curr]
+					defGroup, defOk := recursion[def]
+					if currOk && defOk && defGroup == group && o.AllowMutualRecursion {
+						printer.Ok(Finding{
+							Pos:       node.Pos(),
+							DefPos:    def.Pos(),
+							Kind:      Func,
+							Direction: refOrder[Func],
+							Name:      node.Name,
+							Message:   fmt.Sprintf("func reference %s is mutually recursive with %s", node.Name, curr.Name()),
+						})
+						break
+					}
+				}
+
+				// Handle interface method selections as type references.
+				// If this is a method selection, and the receiver is an interface type,
+				// treat it as a reference to the interface type (not a function).
+				// Method expressions (T.M or (*T).M) name the method itself, so they are
+				// always func references; the receiver type T is checked on its own ident.
+				if sel := selOfIdent[node]; sel != nil && sel.Kind() != types.MethodExpr {
+					// A method promoted through embedded fields is selected on the type of the
+					// last of them, as in s.Read on struct{ io.Reader }, which is then ordered
+					// like r.Read on the interface.
+					recv := derefRecv(embeddedRecv(sel))
+					handled := false
+					switch rt := recv.(type) {
+					case *types.Named:
+						if _, ok := rt.Underlying().(*types.Interface); ok {
+							// Count this as a reference to the named interface type that declares
+							// the method, or to the last one in the package on the way to it.
+							declarer, crossed := methodDeclarer(rt, node.Name, pass.Pkg)
+							if crossed {
+								skip(fmt.Sprintf("interface method %s of %s is promoted from another package",
+									node.Name, rt.Obj().Name()))
+							}
+							check(node, declarer.Obj(), IfaceType)
+							handled = true
+						}
+					case *types.Interface:
+						// Unnamed interface type; nothing to order against at package scope.
+						i := fmt.Sprintf("skipping interface method reference %s on unnamed interface type", node.Name)
+						skip(i)
+						handled = true
+					case *types.TypeParam:
+						// Method selected via a type parameter's interface constraint.
+						n := rt.Obj().Name()
+						pos := fmt.Sprintf("skipping method reference %s on type parameter %s", node.Name, n)
+						skip(pos)
+						handled = true
+					}
+					if handled {
+						break
+					}
+				}
+
+				if def.Parent() != nil && def.Parent() != def.Pkg().Scope() {
+					pos := position(def.Parent().Pos())
+					i := fmt.Sprintf("skipping func ident %s with inner parent scope %s", node.Name, pos)
+					skip(i)
+				} else {
+					check(node, def, Func)
+				}
+
+			case *types.TypeName:
+				if def.Pkg() == nil {
+					skip("skipping predeclared type " + node.Name)
+					break
+				}
+				if def.Parent() != def.Pkg().Scope() && !localDecl(def) {
+					pos := position(def.Parent().Pos())
+					i := fmt.Sprintf("skipping type ident %s with inner parent scope %s", node.Name, pos)
+					skip(i)
+					noteShadowing(def)
+					break
+				}
+				if def.IsAlias() && o.AliasMode == AliasModeTarget {
+					target, ok := types.Unalias(def.Type()).(*types.Named)
+					if !ok || target.Obj().Pkg() != pass.Pkg {
+						skip(fmt.Sprintf("skipping alias %s of type %s outside the package", node.Name, def.Type()))
+						break
+					}
+					def = target.Obj()
+				}
+
+				// Only the type named in the receiver list of a method declaration is a RecvType
+				// reference. Within the signature and body of that method, further references to
+				// the same type are not checked again, whether by value or through a pointer. Every
+				// other type, including the receiver type of a sibling method, is a plain Type
+				// reference. Type parameter lists are part of the FuncType, so their constraints are
+				// never RecvType references.
+				if funcDecl != nil && beforeFuncType {
+					check(node, def, RecvType)
+					recvType = def
+					break
+				}
+				if funcDecl != nil && recvType == def {
+					// Reference to the receiver type within a method type or body.
+					break
+				}
+				check(node, def, Type)
+
+			case *types.Builtin:
+				// Built-in functions like len, make, panic, etc.
+				skip("skipping builtin " + node.Name)
+			case *types.PkgName:
+				// Package qualifier in selectors like fmt.Println.
+				check(node, def, Pkg)
+			case *types.Label:
+				if !gotoLabels[node] {
+					skip("skipping label " + node.Name)
+					break
+				}
+				check(node, def, Label)
+			default:
+				skip(fmt.Sprintf("unexpected ident def type %T for %q", pass.TypesInfo.Uses[node], node.Name))
+			}
+		}
+
+		return true
+	})
+
+	if o.VarInitCycles {
+		for _, cycle := range varInitCycles(pass) {
+			names := make([]string, 0, len(cycle))
+			for _, v := range cycle {
+				names = append(names, v.Name())
+			}
+			// The first var depends on the next one in the cycle, or on itself through funcs.
+			f := Finding{
+				Pos:     cycle[0].Pos(),
+				DefPos:  cycle[1%len(cycle)].Pos(),
+				Kind:    Var,
+				Name:    cycle[0].Name(),
+				Message: "initialization cycle of vars " + strings.Join(names, ", "),
+			}
+			if !suppressed(f) {
+				report(f)
+			}
+		}
+	}
+
+	if o.MethodsAfterType {
+		for _, m := range methodsBeforeType(pass) {
+			f := Finding{
+				Pos:     m.decl.Name.Pos(),
+				DefPos:  m.recv.Pos(),
+				Kind:    RecvType,
+				Name:    m.recv.Name(),
+				Message: fmt.Sprintf("method %s is declared before its receiver type %s (see -methods-after-type)", funcDeclName(m.decl), m.recv.Name()),
+			}
+			if !suppressed(f) {
+				report(f)
+			}
+		}
+	}
+
+	if o.MethodsGrouped {
+		for _, m := range ungroupedMethods(pass) {
+			f := Finding{
+				Pos:    m.decl.Name.Pos(),
+				DefPos: m.prev.Name.Pos(),
+				Kind:   RecvType,
+				Name:   m.recv.Name(),
+				Message: fmt.Sprintf("method %s is separated from the previous method %s of %s by other declarations (see -methods-grouped)",
+					funcDeclName(m.decl), funcDeclName(m.prev), m.recv.Name()),
+			}
+			if !suppressed(f) {
+				report(f)
+			}
+		}
+	}
+
+	if o.ConstructorAfterType {
+		for _, c := range misplacedConstructors(pass, o.ConstructorPrefix) {
+			f := Finding{
+				Pos:    c.decl.Name.Pos(),
+				DefPos: c.typ.Pos(),
+				Kind:   Type,
+				Name:   c.typ.Name(),
+				Message: fmt.Sprintf("constructor %s is not declared right after its type %s (see -constructor-after-type)",
+					c.decl.Name.Name, c.typ.Name()),
+			}
+			if !suppressed(f) {
+				report(f)
+			}
+		}
+	}
+
+	for _, m := range misplacedSpecialFuncs(pass, o.InitPosition, o.MainPosition) {
+		where := "the first declaration of its file"
+		if m.position == FuncPositionAfterVars {
+			where = "declared right after the const and var declarations of its file"
+		}
+		f := Finding{
+			Pos:     m.decl.Name.Pos(),
+			DefPos:  m.other.Pos(),
+			Kind:    Func,
+			Name:    m.decl.Name.Name,
+			Message: fmt.Sprintf("%s func is not %s (see -%s-position)", m.decl.Name.Name, where, m.decl.Name.Name),
+		}
+		if !suppressed(f) {
+			report(f)
+		}
+	}
+
+	if o.ReportCrossFileDensity && pkgRefs > 0 && len(pass.Files) > 0 {
+		density := float64(crossFileRefs) / float64(pkgRefs)
+		f := Finding{
+			Pos: pass.Files[0].Package,
+			Message: fmt.Sprintf("%.0f%% of references (%d of %d) are to definitions in other files of the package",
+				100*density, crossFileRefs, pkgRefs),
+		}
+		if density > o.CrossFileDensityThreshold {
+			f.Message += fmt.Sprintf(", above %.0f%%; the file layout may hurt readability",
+				100*o.CrossFileDensityThreshold)
+			printer.Warn(f)
+		} else {
+			printer.Info(f)
+		}
+	}
+
+	if o.SpreadThreshold > 0 {
+		decls := slices.SortedFunc(maps.Keys(spread), func(a, b *ast.FuncDecl) int { return cmp.Compare(a.Pos(), b.Pos()) })
+		for _, decl := range decls {
+			if len(spread[decl]) <= o.SpreadThreshold {
+				continue
+			}
+			names := make([]string, 0, len(spread[decl]))
+			for obj := range spread[decl] {
+				names = append(names, qualifiedName(obj))
+			}
+			slices.Sort(names)
+			printer.Info(Finding{
+				Pos: decl.Name.Pos(),
+				Message: fmt.Sprintf("func %s references %d package-scope declarations, more than %d (see -spread-threshold): %s",
+					funcDeclName(decl), len(names), o.SpreadThreshold, strings.Join(names, ", ")),
+			})
+		}
+	}
+
+	if o.WarnShadowing {
+		locals := slices.SortedFunc(maps.Keys(shadows), func(a, b types.Object) int { return cmp.Compare(a.Pos(), b.Pos()) })
+		for _, local := range locals {
+			outer := shadows[local]
+			kind := declKind(outer)
+			if !referenced[outer] || o.RefOrder[kind] == Ignore {
+				continue
+			}
+			printer.Info(Finding{
+				Pos:    local.Pos(),
+				DefPos: outer.Pos(),
+				Kind:   kind,
+				Name:   local.Name(),
+				Message: fmt.Sprintf("local %s %s shadows package-scope %s %s (%s), whose references are ordered (see -warn-shadowing)",
+					declKind(local), local.Name(), kind, outer.Name(), position(outer.Pos())),
+			})
+		}
+	}
+
+	if o.ReportUnreferenced {
+		for _, obj := range unreferencedDecls(pass, referenced, o.IncludeGenerated, o.IncludeExportedUnreferenced) {
+			kind := declKind(obj)
+			printer.Info(Finding{
+				Pos:     obj.Pos(),
+				DefPos:  obj.Pos(),
+				Kind:    kind,
+				Name:    obj.Name(),
+				Message: fmt.Sprintf("%s %s is never referenced in the package (see -report-unreferenced)", kind, obj.Name()),
+			})
+		}
+	}
+
+	var violations []funcViolation
+	if o.ReportFuncViolations {
+		for decl, n := range funcViolations {
+			violations = append(violations, funcViolation{pos: decl.Name.Pos(), name: funcDeclName(decl), count: n})
+		}
+		sort.Slice(violations, func(i, j int) bool {
+			if violations[i].count != violations[j].count {
+				return violations[i].count > violations[j].count
+			}
+			return violations[i].pos < violations[j].pos
+		})
+	}
+
+	return checkResult{findings: printer.findings, baselineOut: baselineOut, funcViolations: violations}
+}
+
+func (c *collector) Error(f Finding) { c.add(f, SeverityError) }
+
+func (c *collector) Warn(f Finding) { c.add(f, SeverityWarning) }
+
+func (c *collector) Info(f Finding) { c.add(f, SeverityInfo) }
+
+func (c *collector) Ok(f Finding) { c.add(f, SeverityOk) }
+
+func (c *collector) Flush() {}
+
+func (c *collector) add(f Finding, severity Severity) {
+	f.Severity = severity
+	if c.keep {
+		c.findings = append(c.findings, f)
+	}
+	if c.stream != nil {
+		c.stream(f)
+	}
+}
+
+// ignoredName returns where def is listed in names, a set of names from -ignore lists
+// mapped to their source, by its plain, receiver qualified or package qualified name.
+func ignoredName(names map[string]string, def types.Object) (string, bool) {
+	if len(names) == 0 {
+		return "", false
+	}
+	candidates := []string{def.Name()}
+	if fn, ok := def.(*types.Func); ok {
+		if recv := fn.Signature().Recv(); recv != nil {
+			if named, ok := derefRecv(recv.Type()).(*types.Named); ok {
+				candidates = append(candidates, named.Obj().Name()+"."+def.Name())
+			}
+		}
+	}
+	if def.Pkg() != nil {
+		for _, name := range candidates {
+			candidates = append(candidates, def.Pkg().Name()+"."+name)
+		}
+	}
+	for _, name := range candidates {
+		if source, ok := names[name]; ok {
+			return source, true
+		}
+	}
+	return "", false
+}
+
+// isErrorMethod reports whether def is the Error method of the predeclared error interface,
+// or the interface itself as the declarer of the method.
+func isErrorMethod(def types.Object) bool {
+	if def == errorType {
+		return true
+	}
+	fn, ok := def.(*types.Func)
+	if !ok || fn.Name() != "Error" || fn.Signature().Recv() == nil {
+		return false
+	}
+	return types.Identical(fn.Signature().Recv().Type(), errorType.Type())
+}
+
+// packageScopeObject returns the package-scope declaration of pkg that def refers to: def
+// itself, or the generic origin of an instantiated func or method. It returns nil for
+// locals, fields and other objects that are not declared at package scope.
+func packageScopeObject(pkg *types.Package, def types.Object) types.Object {
+	if fn, ok := def.(*types.Func); ok {
+		fn = fn.Origin()
+		if recv := fn.Signature().Recv(); recv != nil {
+			if types.IsInterface(recv.Type()) {
+				// Interface methods are declared within their interface type.
+				return nil
+			}
+			return fn
+		}
+		def = fn
+	}
+	if def.Parent() != pkg.Scope() {
+		return nil
+	}
+	return def
+}
+
+// qualifiedName names obj for messages, with the receiver type of methods, as in "T.String".
+func qualifiedName(obj types.Object) string {
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return obj.Name()
+	}
+	recv := fn.Signature().Recv()
+	if recv == nil {
+		return obj.Name()
+	}
+	if named, ok := derefRecv(recv.Type()).(*types.Named); ok {
+		return named.Obj().Name() + "." + obj.Name()
+	}
+	return obj.Name()
+}
+
+// derefRecv strips the pointer, if any, from a selection receiver type, looking through
+// aliases as in "type A = *T", down to the type that declares the selected method. Go
+// selects methods through a single pointer and never through named pointer types like
+// "type P *T", so other types are returned unaliased but otherwise unchanged.
+func derefRecv(t types.Type) types.Type {
+	if p, ok := types.Unalias(t).(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	return types.Unalias(t)
+}
+
+// declaresField reports whether field is one of the fields declared by the struct type tn,
+// not counting promoted fields.
+func declaresField(tn *types.TypeName, field *types.Var) bool {
+	st, ok := tn.Type().Underlying().(*types.Struct)
+	if !ok {
+		return false
+	}
+	for i := range st.NumFields() {
+		if st.Field(i) == field {
+			return true
+		}
+	}
+	return false
+}
+
+// funcDeclName names a func declaration, as in "run", "(T).String" or "(*T).Set".
+func funcDeclName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return decl.Name.Name
+	}
+	return "(" + types.ExprString(decl.Recv.List[0].Type) + ")." + decl.Name.Name
+}
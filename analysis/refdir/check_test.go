@@ -0,0 +1,184 @@
+package refdir
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// BenchmarkCheck checks a synthetic package of about 20k lines dominated by selector expressions.
+func BenchmarkCheck(b *testing.B) {
+	pass := syntheticPass(b, 1000)
+	opts := DefaultOptions()
+	opts.init()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		opts.check(pass, nil)
+	}
+}
+
+func TestCheckUnresolvedLabels(t *testing.T) {
+	pass, errs := newTestPass(t, `package p
+
+func f(n int) int {
+	if n < 0 {
+		goto missing
+	}
+	goto done
+	n++
+done:
+	return n
+}
+`)
+	if len(errs) == 0 {
+		t.Fatal("Expected type errors for the missing label")
+	}
+	findings, err := Check(pass, Options{RefOrder: map[RefKind]Direction{Label: Down}})
+	if err != nil {
+		t.Fatalf("Failed to check: %v", err)
+	}
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			t.Errorf("Unexpected error finding: %s", f.Message)
+		}
+	}
+}
+
+func TestCheckPositionWithoutFile(t *testing.T) {
+	pass, errs := newTestPass(t, `package p
+
+func f() {}
+
+func g() {
+	f()
+}
+`)
+	if len(errs) > 0 {
+		t.Fatalf("Failed to type check: %v", errs)
+	}
+	// Move the reference to f outside of every file of the file set.
+	for ident, obj := range pass.TypesInfo.Uses {
+		if obj.Name() == "f" {
+			ident.NamePos = token.Pos(pass.Fset.Base() + 100)
+		}
+	}
+	findings, err := Check(pass, Options{})
+	if err != nil {
+		t.Fatalf("Failed to check: %v", err)
+	}
+	var found bool
+	for _, f := range findings {
+		found = found || f.Message == "func reference f: cannot determine file for position"
+	}
+	if !found {
+		t.Errorf("Expected an info finding for the reference without a file, got %+v", findings)
+	}
+}
+
+func TestCheckDefinitionWithoutFile(t *testing.T) {
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for name, src := range map[string]string{
+		"p.go":      "package p\n\nfunc g() {\n\tf()\n}\n",
+		"linked.go": "package p\n\nfunc f() {}\n",
+	} {
+		file, err := parser.ParseFile(fset, name, src, 0)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", name, err)
+		}
+		files = append(files, file)
+	}
+	info := &types.Info{
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+	}
+	pkg, err := new(types.Config).Check("p", fset, files, info)
+	if err != nil {
+		t.Fatalf("Failed to type check: %v", err)
+	}
+	// Check p.go alone, with a file set that lacks the file declaring f, as for
+	// declarations without Go source.
+	pass := &analysis.Pass{Fset: token.NewFileSet(), Pkg: pkg, TypesInfo: info, ResultOf: map[*analysis.Analyzer]any{}}
+	for _, file := range files {
+		if tf := fset.File(file.Pos()); tf.Name() == "p.go" {
+			pass.Fset.AddExistingFiles(tf)
+			pass.Files = []*ast.File{file}
+		}
+	}
+	findings, err := Check(pass, Options{})
+	if err != nil {
+		t.Fatalf("Failed to check: %v", err)
+	}
+	var found bool
+	for _, f := range findings {
+		found = found || f.Message == "func reference f: definition has no Go source position; likely cgo/asm/builtin"
+	}
+	if !found {
+		t.Errorf("Expected an info finding for the definition without a file, got %+v", findings)
+	}
+}
+
+// syntheticPass type checks a package with n types, each with a method calling through the
+// previous type both via a concrete and an interface selection.
+func syntheticPass(tb testing.TB, n int) *analysis.Pass {
+	tb.Helper()
+	var src strings.Builder
+	src.WriteString("package p\n\ntype Caller interface {\n\tCall() int\n}\n")
+	fmt.Fprintf(&src, "\ntype T0 struct {\n\tv int\n}\n\nfunc (t T0) Call() int {\n\treturn t.v\n}\n")
+	for i := 1; i < n; i++ {
+		fmt.Fprintf(&src, `
+type T%[1]d struct {
+	prev T%[2]d
+	iface Caller
+	v int
+}
+
+func (t T%[1]d) Call() int {
+	a := t.prev.Call()
+	b := t.iface.Call()
+	c := t.prev.v
+	return a + b + c + t.v
+}
+`, i, i-1)
+	}
+	pass, errs := newTestPass(tb, src.String())
+	if len(errs) > 0 {
+		tb.Fatalf("Failed to type check: %v", errs)
+	}
+	return pass
+}
+
+// newTestPass type checks src as the single file of a package, keeping whatever type
+// information is available despite type errors, which are returned.
+func newTestPass(tb testing.TB, src string) (*analysis.Pass, []error) {
+	tb.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		tb.Fatalf("Failed to parse: %v", err)
+	}
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+	}
+	var errs []error
+	conf := types.Config{Error: func(err error) { errs = append(errs, err) }}
+	pkg, _ := conf.Check("p", fset, []*ast.File{file}, info)
+	return &analysis.Pass{
+		Fset:      fset,
+		Files:     []*ast.File{file},
+		Pkg:       pkg,
+		TypesInfo: info,
+		ResultOf:  map[*analysis.Analyzer]any{},
+	}, errs
+}
@@ -1,6 +1,10 @@
 package color
 
-import "runtime"
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+)
 
 type Color string
 
@@ -31,3 +35,38 @@ func init() {
 }
 
 func Colorize(c Color, s string) string { return string(c) + s + string(Reset) }
+
+// Names lists the color names accepted by Lookup, besides 256-color codes.
+var Names = []string{"red", "green", "yellow", "blue", "purple", "cyan", "gray", "white"}
+
+// Lookup returns the color with the given name, one of Names, or the 256-color code from
+// "0" to "255".
+func Lookup(name string) (Color, bool) {
+	switch name {
+	case "red":
+		return Red, true
+	case "green":
+		return Green, true
+	case "yellow":
+		return Yellow, true
+	case "blue":
+		return Blue, true
+	case "purple":
+		return Purple, true
+	case "cyan":
+		return Cyan, true
+	case "gray":
+		return Gray, true
+	case "white":
+		return White, true
+	}
+	code, err := strconv.Atoi(name)
+	if err != nil || code < 0 || code > 255 || strconv.Itoa(code) != name {
+		return "", false
+	}
+	if Reset == "" {
+		// Colors are disabled.
+		return "", true
+	}
+	return Color(fmt.Sprintf("\033[38;5;%dm", code)), true
+}
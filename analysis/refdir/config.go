@@ -0,0 +1,248 @@
+package refdir
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"go.yaml.in/yaml/v3"
+	"golang.org/x/tools/go/analysis"
+)
+
+// configFileNames are the config files looked for in each directory, in order of preference.
+var configFileNames = []string{".refdir.yaml", ".refdir.yml", ".refdir.json"}
+
+// configFile is the serialized form of a config file. Keys match the flags they set.
+type configFile struct {
+	Directions                map[RefKind]Direction `json:"directions,omitempty"                   yaml:"directions,omitempty"`
+	TestDirections            map[RefKind]Direction `json:"test-directions,omitempty"              yaml:"test-directions,omitempty"`
+	Verbose                   *bool                 `json:"verbose,omitempty"                      yaml:"verbose,omitempty"`
+	Color                     *bool                 `json:"color,omitempty"                        yaml:"color,omitempty"`
+	MinDistance               *int                  `json:"min-distance,omitempty"                 yaml:"min-distance,omitempty"`
+	CrossFileDensityThreshold *float64              `json:"cross-file-density-threshold,omitempty" yaml:"cross-file-density-threshold,omitempty"`
+	SpreadThreshold           *int                  `json:"spread-threshold,omitempty"             yaml:"spread-threshold,omitempty"`
+	Adjacency                 *int                  `json:"adjacency,omitempty"                    yaml:"adjacency,omitempty"`
+	ExcludePaths              []string              `json:"exclude-paths,omitempty"                yaml:"exclude-paths,omitempty"`
+	Ignore                    map[RefKind][]string  `json:"ignore,omitempty"                       yaml:"ignore,omitempty"`
+}
+
+// loadedConfig is the nearest config file above a directory, if any.
+type loadedConfig struct {
+	path string
+	cfg  *configFile
+	err  error
+}
+
+// configCache remembers the config found for each directory, so each file is parsed once.
+type configCache struct {
+	mu   sync.Mutex
+	dirs map[string]loadedConfig
+}
+
+// withConfig returns the options for pass with the nearest config file applied, and an
+// info finding naming that file. It returns o itself when there is no config file.
+func (o *Options) withConfig(pass *analysis.Pass) (*Options, []Finding, error) {
+	if !o.Config || len(pass.Files) == 0 {
+		return o, nil, nil
+	}
+	dir := filepath.Dir(pass.Fset.File(pass.Files[0].Pos()).Name())
+	loaded := o.shared.configs.find(dir)
+	if loaded.path == "" {
+		return o, nil, nil
+	}
+	if loaded.err != nil {
+		return nil, nil, fmt.Errorf("failed to load config %s: %w", loaded.path, loaded.err)
+	}
+	info := Finding{Pos: pass.Files[0].Package, Severity: SeverityInfo, Message: "loaded config " + loaded.path}
+	return o.applyConfig(loaded.path, loaded.cfg), []Finding{info}, nil
+}
+
+func (c *configCache) find(dir string) loadedConfig {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if loaded, ok := c.dirs[dir]; ok {
+		return loaded
+	}
+	if c.dirs == nil {
+		c.dirs = make(map[string]loadedConfig)
+	}
+	loaded := findConfig(dir)
+	c.dirs[dir] = loaded
+	return loaded
+}
+
+// applyConfig returns a copy of o with the settings of cfg, read from path, that were not set
+// by flags. Ignored names are added to those of o.
+func (o *Options) applyConfig(path string, cfg *configFile) *Options {
+	opts := *o
+	opts.RefOrder = maps.Clone(o.RefOrder)
+	for kind, dir := range cfg.Directions {
+		if !o.isFlagSet(string(kind) + "-dir") {
+			opts.RefOrder[kind] = dir
+		}
+	}
+	opts.TestRefOrder = maps.Clone(o.TestRefOrder)
+	for kind, dir := range cfg.TestDirections {
+		if !o.isFlagSet("test-" + string(kind) + "-dir") {
+			opts.TestRefOrder[kind] = dir
+		}
+	}
+	if cfg.Verbose != nil && !o.isFlagSet("verbose") {
+		opts.Verbose = *cfg.Verbose
+	}
+	if cfg.Color != nil && !o.isFlagSet("color") {
+		opts.Colorize = *cfg.Color
+		opts.colorSet = true
+	}
+	if cfg.MinDistance != nil && !o.isFlagSet("min-distance") {
+		opts.MinDistance = *cfg.MinDistance
+	}
+	if cfg.CrossFileDensityThreshold != nil && !o.isFlagSet("cross-file-density-threshold") {
+		opts.CrossFileDensityThreshold = *cfg.CrossFileDensityThreshold
+	}
+	if cfg.SpreadThreshold != nil && !o.isFlagSet("spread-threshold") {
+		opts.SpreadThreshold = *cfg.SpreadThreshold
+	}
+	if cfg.Adjacency != nil && !o.isFlagSet("adjacency") {
+		opts.Adjacency = *cfg.Adjacency
+	}
+	if cfg.ExcludePaths != nil && !o.isFlagSet("exclude-paths") {
+		opts.ExcludePaths = cfg.ExcludePaths
+	}
+	if len(cfg.Ignore) > 0 {
+		opts.ignoreNames = make(map[RefKind]map[string]string, len(o.ignoreNames)+len(cfg.Ignore))
+		for kind, names := range o.ignoreNames {
+			opts.ignoreNames[kind] = maps.Clone(names)
+		}
+		addIgnoreNames(opts.ignoreNames, cfg.Ignore, "config "+path)
+	}
+	return &opts
+}
+
+// dumpConfigOnce writes the -config-dump file for the first package of the run.
+func (o *Options) dumpConfigOnce() error {
+	if !o.shared.configDumped.CompareAndSwap(false, true) {
+		return nil
+	}
+	if err := o.dumpConfig(o.ConfigDump); err != nil {
+		return fmt.Errorf("failed to write config dump: %w", err)
+	}
+	return nil
+}
+
+// dumpConfig writes the settings of o that config files hold to path, as JSON if its
+// extension is .json and as YAML otherwise, so that reading it back yields the same options.
+func (o *Options) dumpConfig(path string) error {
+	cfg := configFile{
+		Directions:                o.RefOrder,
+		Verbose:                   ptr(o.Verbose),
+		MinDistance:               ptr(o.MinDistance),
+		CrossFileDensityThreshold: ptr(o.CrossFileDensityThreshold),
+		SpreadThreshold:           ptr(o.SpreadThreshold),
+		Adjacency:                 ptr(o.Adjacency),
+		ExcludePaths:              o.ExcludePaths,
+	}
+	if len(o.TestRefOrder) > 0 {
+		cfg.TestDirections = o.TestRefOrder
+	}
+	// Without a flag or config file, colors depend on the terminal and are left out.
+	if o.colorSet || o.isFlagSet("color") {
+		cfg.Color = ptr(o.Colorize)
+	}
+	for kind, names := range o.ignoreNames {
+		if cfg.Ignore == nil {
+			cfg.Ignore = make(map[RefKind][]string, len(o.ignoreNames))
+		}
+		cfg.Ignore[kind] = slices.Sorted(maps.Keys(names))
+	}
+
+	var buf bytes.Buffer
+	if filepath.Ext(path) == ".json" {
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(cfg); err != nil {
+			return err
+		}
+	} else {
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(cfg); err != nil {
+			return err
+		}
+		if err := enc.Close(); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o600)
+}
+
+// ptr returns a pointer to a copy of v, for the optional keys of configFile.
+func ptr[T any](v T) *T { return &v }
+
+// findConfig looks for a config file in dir and its parents.
+func findConfig(dir string) loadedConfig {
+	for {
+		for _, name := range configFileNames {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err == nil {
+				cfg, err := readConfig(path)
+				return loadedConfig{path: path, cfg: cfg, err: err}
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return loadedConfig{}
+		}
+		dir = parent
+	}
+}
+
+// readConfig parses a YAML or JSON config file, rejecting unknown keys and values.
+func readConfig(path string) (*configFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg configFile
+	if filepath.Ext(path) == ".json" {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&cfg)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&cfg)
+		if errors.Is(err, io.EOF) {
+			// An empty file sets nothing.
+			err = nil
+		}
+	}
+	if err != nil {
+		// Kinds and directions are validated while decoding, see RefKind.UnmarshalText.
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// addIgnoreNames adds the names listed per kind in lists to ignored, recording source as
+// where they were listed. Names already in ignored keep their source.
+func addIgnoreNames(ignored map[RefKind]map[string]string, lists map[RefKind][]string, source string) {
+	for kind, names := range lists {
+		if ignored[kind] == nil {
+			ignored[kind] = make(map[string]string, len(names))
+		}
+		for _, name := range names {
+			if _, ok := ignored[kind][name]; !ok {
+				ignored[kind][name] = source
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package refdir
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// misplacedConstructor is a constructor not declared right after the type it returns.
+type misplacedConstructor struct {
+	decl *ast.FuncDecl
+	typ  *types.TypeName
+}
+
+// misplacedConstructors returns the constructors of the package, funcs named prefix or
+// prefix followed by an upper case letter whose first result is a type of the package,
+// that are not declared right after that type in its file, for -constructor-after-type.
+// Only other constructors of the type may sit between them. Constructors in other files
+// than their type are not checked.
+func misplacedConstructors(pass *analysis.Pass, prefix string) []misplacedConstructor {
+	var misplaced []misplacedConstructor
+	for _, file := range pass.Files {
+		// Index in file.Decls of each type declared in the file.
+		typeIndex := make(map[*types.TypeName]int)
+		// Type constructed by each func, by index in file.Decls.
+		constructs := make(map[int]*types.TypeName)
+		for i, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					if ts, ok := spec.(*ast.TypeSpec); ok {
+						if tn, ok := pass.TypesInfo.Defs[ts.Name].(*types.TypeName); ok {
+							typeIndex[tn] = i
+						}
+					}
+				}
+			case *ast.FuncDecl:
+				if tn := constructedType(pass, decl, prefix); tn != nil {
+					constructs[i] = tn
+				}
+			}
+		}
+
+		for i, decl := range file.Decls {
+			tn := constructs[i]
+			j, ok := typeIndex[tn]
+			if tn == nil || !ok {
+				continue
+			}
+			adjacent := j < i
+			for k := j + 1; k < i && adjacent; k++ {
+				adjacent = constructs[k] == tn
+			}
+			if !adjacent {
+				fd, _ := decl.(*ast.FuncDecl)
+				misplaced = append(misplaced, misplacedConstructor{decl: fd, typ: tn})
+			}
+		}
+	}
+	return misplaced
+}
+
+// constructedType returns the type of the package returned first by fd, if fd is a func
+// named like a constructor with prefix, or nil.
+func constructedType(pass *analysis.Pass, fd *ast.FuncDecl, prefix string) *types.TypeName {
+	rest, ok := strings.CutPrefix(fd.Name.Name, prefix)
+	if first, _ := utf8.DecodeRuneInString(rest); fd.Recv != nil || !ok || (rest != "" && !unicode.IsUpper(first)) {
+		return nil
+	}
+	fn, ok := pass.TypesInfo.Defs[fd.Name].(*types.Func)
+	if !ok || fn.Signature().Results().Len() == 0 {
+		return nil
+	}
+	named, ok := derefRecv(fn.Signature().Results().At(0).Type()).(*types.Named)
+	if !ok || named.Obj().Pkg() != pass.Pkg {
+		return nil
+	}
+	return named.Origin().Obj()
+}
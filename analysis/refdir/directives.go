@@ -0,0 +1,161 @@
+package refdir
+
+import (
+	"go/ast"
+	"go/token"
+	"slices"
+	"strings"
+)
+
+const (
+	ignoreDirectivePrefix = "//refdir:ignore"
+	// fileDirectivePrefix starts a directive like //refdir:func=up,type=down that overrides
+	// directions for the whole file.
+	fileDirectivePrefix = "//refdir:"
+	// nolintDirectivePrefix starts a golangci-lint //nolint or //nolint:refdir directive.
+	nolintDirectivePrefix = "//nolint"
+)
+
+// An ignoreDirective suppresses ordering errors on the line it is written on,
+// either for all kinds or only for the listed ones.
+type ignoreDirective struct {
+	all   bool
+	kinds []RefKind
+}
+
+func (d ignoreDirective) covers(kind RefKind) bool {
+	return d.all || slices.Contains(d.kinds, kind)
+}
+
+// parseIgnoreDirectives collects the //refdir:ignore directives of a file, keyed by line.
+// Kinds that are not in RefKinds are dropped and reported through unknown.
+func parseIgnoreDirectives(
+	fset *token.FileSet,
+	file *ast.File,
+	unknown func(pos token.Pos, kind string),
+) map[int]ignoreDirective {
+	directives := make(map[int]ignoreDirective)
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			rest, ok := strings.CutPrefix(c.Text, ignoreDirectivePrefix)
+			if !ok || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+				continue
+			}
+			directive := ignoreDirective{all: true}
+			if fields := strings.Fields(rest); len(fields) > 0 {
+				directive.all = false
+				for name := range strings.SplitSeq(fields[0], ",") {
+					var kind RefKind
+					if err := kind.Set(name); err == nil {
+						directive.kinds = append(directive.kinds, kind)
+					} else {
+						unknown(c.Pos(), name)
+					}
+				}
+			}
+			directives[fset.Position(c.Pos()).Line] = directive
+		}
+	}
+	return directives
+}
+
+// parseFileDirections collects the directions set by file directives in the comments above
+// the package clause. Invalid kinds or directions are dropped and reported through invalid.
+func parseFileDirections(file *ast.File, invalid func(pos token.Pos, setting string)) map[RefKind]Direction {
+	var directions map[RefKind]Direction
+	for _, group := range file.Comments {
+		if group.End() > file.Package {
+			break
+		}
+		for _, c := range group.List {
+			rest, ok := strings.CutPrefix(c.Text, fileDirectivePrefix)
+			fields := strings.Fields(rest)
+			if !ok || len(fields) == 0 || !strings.Contains(fields[0], "=") {
+				continue
+			}
+			for setting := range strings.SplitSeq(fields[0], ",") {
+				name, value, _ := strings.Cut(setting, "=")
+				var (
+					kind RefKind
+					dir  Direction
+				)
+				if kind.Set(name) != nil || dir.Set(value) != nil {
+					invalid(c.Pos(), setting)
+					continue
+				}
+				if directions == nil {
+					directions = make(map[RefKind]Direction)
+				}
+				directions[kind] = dir
+			}
+		}
+	}
+	return directions
+}
+
+// parseNolintDirectives returns the lines of a file covered by //nolint directives that
+// apply to refdir: bare //nolint, or //nolint with a list naming refdir or all. A directive
+// covers its own line, and the next line too when nothing precedes it on its line.
+func parseNolintDirectives(fset *token.FileSet, file *ast.File) map[int]bool {
+	var lines map[int]bool
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			rest, ok := strings.CutPrefix(c.Text, nolintDirectivePrefix)
+			if !ok || !nolintCoversRefdir(rest) {
+				continue
+			}
+			if lines == nil {
+				lines = make(map[int]bool)
+			}
+			pos := fset.Position(c.Pos())
+			lines[pos.Line] = true
+			if standaloneComment(fset, file, pos) {
+				lines[pos.Line+1] = true
+			}
+		}
+	}
+	return lines
+}
+
+// nolintCoversRefdir reports whether the text following //nolint applies to refdir.
+func nolintCoversRefdir(rest string) bool {
+	if rest == "" || rest[0] == ' ' || rest[0] == '\t' {
+		return true
+	}
+	list, ok := strings.CutPrefix(rest, ":")
+	if !ok {
+		return false
+	}
+	if i := strings.IndexAny(list, " \t"); i >= 0 {
+		list = list[:i]
+	}
+	for name := range strings.SplitSeq(list, ",") {
+		if name = strings.TrimSpace(name); name == analyzerName || name == "all" {
+			return true
+		}
+	}
+	return false
+}
+
+// standaloneComment reports whether no syntax node of file starts before pos on its line.
+func standaloneComment(fset *token.FileSet, file *ast.File, pos token.Position) bool {
+	standalone := true
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil || !standalone {
+			return false
+		}
+		if _, ok := n.(*ast.CommentGroup); ok {
+			return false
+		}
+		start, end := fset.Position(n.Pos()), fset.Position(n.End())
+		if end.Line < pos.Line || start.Line > pos.Line {
+			return false
+		}
+		if start.Line == pos.Line && start.Column < pos.Column {
+			standalone = false
+			return false
+		}
+		return true
+	})
+	return standalone
+}
@@ -0,0 +1,95 @@
+package refdir
+
+import (
+	"go/parser"
+	"go/token"
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestParseIgnoreDirectives(t *testing.T) {
+	src := `package p
+
+var a = 1 //refdir:ignore
+var b = 2 //refdir:ignore func,bogus
+var c = 3 //refdir:ignored
+var d = 4 // refdir:ignore
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	var unknown []string
+	directives := parseIgnoreDirectives(fset, file, func(_ token.Pos, kind string) {
+		unknown = append(unknown, kind)
+	})
+
+	if d := directives[3]; !d.covers(Func) || !d.covers(Const) {
+		t.Errorf("bare directive should cover all kinds, got %+v", d)
+	}
+	if d := directives[4]; !d.covers(Func) || d.covers(Type) {
+		t.Errorf("scoped directive should cover only func, got %+v", d)
+	}
+	for _, line := range []int{5, 6} {
+		if _, ok := directives[line]; ok {
+			t.Errorf("unexpected directive on line %d", line)
+		}
+	}
+	if !slices.Equal(unknown, []string{"bogus"}) {
+		t.Errorf("unexpected unknown kinds %q", unknown)
+	}
+}
+
+func TestParseFileDirections(t *testing.T) {
+	src := `//refdir:func=up,type=sideways,bogus=down
+//refdir:ignore
+
+// Package p has a doc comment.
+//refdir:var=either
+package p
+
+//refdir:const=down
+var a = 1
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	var invalid []string
+	directions := parseFileDirections(file, func(_ token.Pos, setting string) {
+		invalid = append(invalid, setting)
+	})
+
+	want := map[RefKind]Direction{Func: Up, Var: Either}
+	if !maps.Equal(directions, want) {
+		t.Errorf("unexpected directions %v, want %v", directions, want)
+	}
+	if !slices.Equal(invalid, []string{"type=sideways", "bogus=down"}) {
+		t.Errorf("unexpected invalid settings %q", invalid)
+	}
+}
+
+func TestParseNolintDirectives(t *testing.T) {
+	src := `package p
+
+var a = 1 //nolint
+var b = 2 //nolint:gocritic,refdir // Reason.
+var c = 3 //nolint:gocritic
+
+//nolint:all
+var d = 4
+var e = 5 //nolintx
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	lines := slices.Sorted(maps.Keys(parseNolintDirectives(fset, file)))
+	if want := []int{3, 4, 7, 8}; !slices.Equal(lines, want) {
+		t.Errorf("got covered lines %v, want %v", lines, want)
+	}
+}
@@ -0,0 +1,59 @@
+package refdir
+
+import "go/types"
+
+// methodDeclarer follows the chain of embedded interfaces from iface to the interface that
+// declares the method name. It returns the last interface of the chain declared in pkg,
+// and whether the chain continues into another package.
+func methodDeclarer(iface *types.Named, name string, pkg *types.Package) (*types.Named, bool) {
+	path := embeddingPath(iface, name)
+	if len(path) == 0 {
+		return iface, false
+	}
+	declarer := path[0]
+	for _, named := range path[1:] {
+		if named.Obj().Pkg() != pkg {
+			return declarer, true
+		}
+		declarer = named
+	}
+	return declarer, false
+}
+
+// embeddedRecv returns the type that declares the method or field selected by sel: the type
+// of the last embedded field on the path of a promoted selection, or the receiver type
+// otherwise.
+func embeddedRecv(sel *types.Selection) types.Type {
+	t := sel.Recv()
+	path := sel.Index()
+	for _, i := range path[:len(path)-1] {
+		st, ok := derefRecv(t).Underlying().(*types.Struct)
+		if !ok {
+			return sel.Recv()
+		}
+		t = st.Field(i).Type()
+	}
+	return t
+}
+
+// embeddingPath returns the named interfaces from named to the one declaring the method
+// name, through embedded named interfaces. It returns nil if there is no such path.
+func embeddingPath(named *types.Named, name string) []*types.Named {
+	iface, ok := named.Underlying().(*types.Interface)
+	if !ok {
+		return nil
+	}
+	for i := range iface.NumExplicitMethods() {
+		if iface.ExplicitMethod(i).Name() == name {
+			return []*types.Named{named}
+		}
+	}
+	for i := range iface.NumEmbeddeds() {
+		if embedded, ok := types.Unalias(iface.EmbeddedType(i)).(*types.Named); ok {
+			if path := embeddingPath(embedded, name); path != nil {
+				return append([]*types.Named{named}, path...)
+			}
+		}
+	}
+	return nil
+}
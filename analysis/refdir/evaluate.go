@@ -0,0 +1,34 @@
+package refdir
+
+// Result is the outcome of comparing the positions of a reference and its definition.
+type Result string
+
+const (
+	// ResultOk means the reference is in the required direction, or the direction accepts any.
+	ResultOk Result = "ok"
+	// ResultErrorBefore means the reference is before its definition, against an up direction.
+	ResultErrorBefore Result = "error-before"
+	// ResultErrorAfter means the reference is after its definition, against a down direction.
+	ResultErrorAfter Result = "error-after"
+	// ResultSameLine means the reference is on the line of its definition.
+	ResultSameLine Result = "same-line"
+)
+
+// Evaluate decides whether a reference on refLine to a definition on defLine follows dir.
+//
+//	ref vs def  down        up           either    ignore
+//	same line   SameLine    SameLine     SameLine  SameLine
+//	before      Ok          ErrorBefore  Ok        Ok
+//	after       ErrorAfter  Ok           Ok        Ok
+func Evaluate(refLine, defLine int, dir Direction) Result {
+	switch {
+	case refLine == defLine:
+		return ResultSameLine
+	case refLine < defLine && dir == Up:
+		return ResultErrorBefore
+	case refLine > defLine && dir == Down:
+		return ResultErrorAfter
+	default:
+		return ResultOk
+	}
+}
@@ -0,0 +1,20 @@
+package refdir
+
+import "testing"
+
+func TestEvaluate(t *testing.T) {
+	want := map[Direction][3]Result{
+		// Results for a reference before, on, and after the definition line.
+		Down:   {ResultOk, ResultSameLine, ResultErrorAfter},
+		Up:     {ResultErrorBefore, ResultSameLine, ResultOk},
+		Either: {ResultOk, ResultSameLine, ResultOk},
+		Ignore: {ResultOk, ResultSameLine, ResultOk},
+	}
+	for _, dir := range Directions {
+		for i, refLine := range []int{9, 10, 11} {
+			if got := Evaluate(refLine, 10, dir); got != want[dir][i] {
+				t.Errorf("Evaluate(%d, 10, %s) = %s, want %s", refLine, dir, got, want[dir][i])
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package refdir
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+)
+
+// worstFuncs is the number of funcs listed in PackageFact.Worst.
+const worstFuncs = 3
+
+// FactsAnalyzer is the default facts analyzer, see NewFactsAnalyzer.
+var FactsAnalyzer = NewFactsAnalyzer(DefaultOptions())
+
+// FuncErrors counts the func ordering errors in the body of a func.
+type FuncErrors struct {
+	Name   string
+	Errors int
+}
+
+// PackageFact summarizes the ordering health of a package. The facts analyzer exports one
+// for each package it checks, so that drivers like go vet cache it with the package and
+// analyzers of dependent packages can aggregate it.
+type PackageFact struct {
+	// Errors and Warnings count the ordering errors and warnings of the package.
+	Errors   int
+	Warnings int
+	// Kinds counts the errors and warnings of each kind.
+	Kinds map[RefKind]int
+	// Worst lists the funcs with the most func ordering errors in their body, most first.
+	Worst []FuncErrors
+}
+
+// NewFactsAnalyzer returns a companion analyzer that checks each package like the analyzer
+// returned by NewWithOptions, without printing its findings. Instead, it exports a
+// PackageFact per package and reports, on each package with ordering issues in itself or
+// its dependencies of the same module, a single diagnostic aggregating their facts.
+//
+// Facts make the driver run the analyzer on all dependencies, including the standard
+// library, so it is meant for repository-wide reports rather than everyday linting.
+func NewFactsAnalyzer(opts Options) *analysis.Analyzer {
+	opts.init()
+	// The worst offenders come from the per-func report.
+	opts.ReportFuncViolations = true
+
+	a := &analysis.Analyzer{
+		Name:      analyzerName + "facts",
+		Doc:       "Export and aggregate per-package summaries of reference-to-declaration ordering issues",
+		Run:       opts.runFacts,
+		Flags:     flag.FlagSet{},
+		Requires:  []*analysis.Analyzer{inspect.Analyzer},
+		FactTypes: []analysis.Fact{new(PackageFact)},
+	}
+	opts.registerFlags(&a.Flags)
+	opts.flags = &a.Flags
+	return a
+}
+
+// runFacts exports the PackageFact of a single package and reports the aggregate.
+func (o *Options) runFacts(pass *analysis.Pass) (any, error) {
+	opts, _, err := o.withConfig(pass)
+	if err != nil {
+		return nil, err
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+	if opts.ConfigDump != "" {
+		//nolint:nilnil // Done.
+		return nil, opts.dumpConfigOnce()
+	}
+	result := opts.check(pass, nil)
+	pass.ExportPackageFact(newPackageFact(result))
+
+	if len(pass.Files) == 0 {
+		//nolint:nilnil // Done.
+		return nil, nil
+	}
+	total := PackageFact{Kinds: make(map[RefKind]int)}
+	var pkgs []string
+	for _, pf := range pass.AllPackageFacts() {
+		fact, ok := pf.Fact.(*PackageFact)
+		if !ok || fact.Errors+fact.Warnings == 0 || !inModule(pass.Module, pf.Package.Path()) {
+			continue
+		}
+		total.Errors += fact.Errors
+		total.Warnings += fact.Warnings
+		for kind, n := range fact.Kinds {
+			total.Kinds[kind] += n
+		}
+		pkgs = append(pkgs, pf.Package.Path()+": "+fact.String())
+	}
+	if len(pkgs) > 0 {
+		slices.Sort(pkgs)
+		noun := "packages"
+		if len(pkgs) == 1 {
+			noun = "package"
+		}
+		pass.Reportf(
+			pass.Files[0].Package,
+			"%s in %d %s: %s",
+			total.String(),
+			len(pkgs),
+			noun,
+			strings.Join(pkgs, "; "),
+		)
+	}
+
+	//nolint:nilnil // Done.
+	return nil, nil
+}
+
+// newPackageFact summarizes the findings of a package.
+func newPackageFact(result checkResult) *PackageFact {
+	fact := &PackageFact{Kinds: make(map[RefKind]int)}
+	for _, f := range result.findings {
+		switch f.Severity {
+		case SeverityError:
+			fact.Errors++
+		case SeverityWarning:
+			fact.Warnings++
+		default:
+			continue
+		}
+		if f.Kind != "" {
+			fact.Kinds[f.Kind]++
+		}
+	}
+	for _, v := range result.funcViolations[:min(len(result.funcViolations), worstFuncs)] {
+		fact.Worst = append(fact.Worst, FuncErrors{Name: v.name, Errors: v.count})
+	}
+	if len(fact.Kinds) == 0 {
+		fact.Kinds = nil
+	}
+	return fact
+}
+
+// inModule reports whether the package with path pkg belongs to mod. Without module
+// information, as in GOPATH mode, all packages do.
+func inModule(mod *analysis.Module, pkg string) bool {
+	if mod == nil || mod.Path == "" {
+		return true
+	}
+	return pkg == mod.Path || strings.HasPrefix(pkg, mod.Path+"/")
+}
+
+func (*PackageFact) AFact() {}
+
+func (f *PackageFact) String() string {
+	s := fmt.Sprintf("%d errors, %d warnings", f.Errors, f.Warnings)
+	if len(f.Kinds) > 0 {
+		kinds := make([]string, 0, len(f.Kinds))
+		for _, kind := range slices.Sorted(maps.Keys(f.Kinds)) {
+			kinds = append(kinds, fmt.Sprintf("%s:%d", kind, f.Kinds[kind]))
+		}
+		s += " (" + strings.Join(kinds, " ") + ")"
+	}
+	if len(f.Worst) > 0 {
+		worst := make([]string, 0, len(f.Worst))
+		for _, fe := range f.Worst {
+			worst = append(worst, fmt.Sprintf("%s (%d)", fe.Name, fe.Errors))
+		}
+		s += "; worst: " + strings.Join(worst, ", ")
+	}
+	return s
+}
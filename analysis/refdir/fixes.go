@@ -0,0 +1,97 @@
+package refdir
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// moveDeclFixes suggests moving the top-level declaration containing def next to the
+// top-level declaration containing ref: just above it when defAbove is set, and just
+// below it otherwise. Only func and type declarations are moved. No fix is returned
+// when the declarations cannot be found or are the same.
+func moveDeclFixes(pass *analysis.Pass, file *ast.File, ref, def token.Pos, defAbove bool) []analysis.SuggestedFix {
+	refDecl, defDecl := enclosingDecl(file, ref), enclosingDecl(file, def)
+	if refDecl == nil || defDecl == nil || refDecl == defDecl || !isMovableDecl(defDecl) || pass.ReadFile == nil {
+		return nil
+	}
+	tf := pass.Fset.File(file.Pos())
+	src, err := pass.ReadFile(tf.Name())
+	if err != nil {
+		return nil
+	}
+
+	defStart, defEnd := declLines(tf, defDecl)
+	declText := string(src[tf.Offset(defStart):tf.Offset(defEnd)])
+	// Also remove one blank line after the declaration; the inserted text brings its own.
+	if defEnd < token.Pos(tf.Base()+tf.Size()) && strings.TrimSpace(lineAt(src, tf.Offset(defEnd))) == "" {
+		defEnd = lineEnd(tf, tf.Line(defEnd))
+	}
+
+	refStart, refEnd := declLines(tf, refDecl)
+	insert := analysis.TextEdit{Pos: refEnd, End: refEnd, NewText: []byte("\n" + declText)}
+	where := "below"
+	if defAbove {
+		insert = analysis.TextEdit{Pos: refStart, End: refStart, NewText: []byte(declText + "\n")}
+		where = "above"
+	}
+	return []analysis.SuggestedFix{{
+		Message: "Move declaration " + where + " its reference",
+		TextEdits: []analysis.TextEdit{
+			{Pos: defStart, End: defEnd},
+			insert,
+		},
+	}}
+}
+
+func enclosingDecl(file *ast.File, pos token.Pos) ast.Decl {
+	for _, decl := range file.Decls {
+		if decl.Pos() <= pos && pos < decl.End() {
+			return decl
+		}
+	}
+	return nil
+}
+
+func isMovableDecl(decl ast.Decl) bool {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		return true
+	case *ast.GenDecl:
+		return d.Tok == token.TYPE
+	default:
+		return false
+	}
+}
+
+// declLines returns the start of the first line of decl, including its doc comment,
+// and the start of the line after its last line.
+func declLines(tf *token.File, decl ast.Decl) (start, end token.Pos) {
+	first := decl.Pos()
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Doc != nil {
+			first = d.Doc.Pos()
+		}
+	case *ast.GenDecl:
+		if d.Doc != nil {
+			first = d.Doc.Pos()
+		}
+	}
+	return tf.LineStart(tf.Line(first)), lineEnd(tf, tf.Line(decl.End()))
+}
+
+// lineEnd returns the start of the line after line, or the end of the file.
+func lineEnd(tf *token.File, line int) token.Pos {
+	if line < tf.LineCount() {
+		return tf.LineStart(line + 1)
+	}
+	return token.Pos(tf.Base() + tf.Size())
+}
+
+func lineAt(src []byte, offset int) string {
+	line, _, _ := strings.Cut(string(src[offset:]), "\n")
+	return line
+}
@@ -0,0 +1,92 @@
+package refdir
+
+import (
+	"go/ast"
+	"go/token"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// misplacedFunc is an init or main func not declared at its required position, with the
+// first declaration that is out of place relative to it.
+type misplacedFunc struct {
+	decl     *ast.FuncDecl
+	other    ast.Decl
+	position FuncPosition
+}
+
+// misplacedSpecialFuncs returns the init funcs, and the main func of a main package, that
+// are not declared at the position required by initPos and mainPos in their file, for
+// -init-position and -main-position. Imports and other init and main funcs may always
+// precede them.
+func misplacedSpecialFuncs(pass *analysis.Pass, initPos, mainPos FuncPosition) []misplacedFunc {
+	var misplaced []misplacedFunc
+	for _, file := range pass.Files {
+		for i, decl := range file.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || !isSpecialFunc(pass, fd) {
+				continue
+			}
+			position := initPos
+			if fd.Name.Name == "main" {
+				position = mainPos
+			}
+			var other ast.Decl
+			switch position {
+			case FuncPositionFirst:
+				for _, d := range file.Decls[:i] {
+					if !isImportOrSpecial(pass, d) {
+						other = d
+						break
+					}
+				}
+			case FuncPositionAfterVars:
+				for _, d := range file.Decls[:i] {
+					if !isImportOrSpecial(pass, d) && !isValueDecl(d) {
+						other = d
+						break
+					}
+				}
+				if other == nil {
+					for _, d := range file.Decls[i+1:] {
+						if isValueDecl(d) {
+							other = d
+							break
+						}
+					}
+				}
+			default:
+				continue
+			}
+			if other != nil {
+				misplaced = append(misplaced, misplacedFunc{decl: fd, other: other, position: position})
+			}
+		}
+	}
+	return misplaced
+}
+
+// isImportOrSpecial reports whether decl is an import declaration or a special func.
+func isImportOrSpecial(pass *analysis.Pass, decl ast.Decl) bool {
+	switch decl := decl.(type) {
+	case *ast.GenDecl:
+		return decl.Tok == token.IMPORT
+	case *ast.FuncDecl:
+		return isSpecialFunc(pass, decl)
+	}
+	return false
+}
+
+// isSpecialFunc reports whether fd is an init func, or the main func of a main package.
+func isSpecialFunc(pass *analysis.Pass, fd *ast.FuncDecl) bool {
+	if fd.Recv != nil {
+		return false
+	}
+	return fd.Name.Name == "init" || (fd.Name.Name == "main" && pass.Pkg.Name() == "main")
+}
+
+// isValueDecl reports whether decl is a const or var declaration.
+func isValueDecl(decl ast.Decl) bool {
+	gd, ok := decl.(*ast.GenDecl)
+	return ok && (gd.Tok == token.CONST || gd.Tok == token.VAR)
+}
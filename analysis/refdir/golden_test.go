@@ -0,0 +1,69 @@
+package refdir
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the output tests")
+
+func TestGoldenJSON(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Format = FormatJSON
+	opts.Verbose = true
+	runGolden(t, opts, "./formats/...", "formats.json")
+}
+
+func TestGoldenSARIF(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Format = FormatSARIF
+	opts.SARIFOut = filepath.Join(t.TempDir(), "refdir.sarif")
+	runGolden(t, opts, "./formats/...", "formats.sarif")
+}
+
+func TestGoldenGrouped(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Format = FormatGrouped
+	opts.Context = 1
+	runGolden(t, opts, "./formats/...", "formats.txt")
+}
+
+// runGolden runs an analyzer with opts over the fixture packages matching pattern, and
+// compares what it writes to opts.Output, or to opts.SARIFOut if set, with the golden file
+// of that name in testdata/golden. File names in the output are relative to the fixtures,
+// so the golden files do not depend on the checkout. Run the tests with -update to rewrite them.
+func runGolden(t *testing.T, opts Options, pattern, golden string) {
+	t.Helper()
+	var out bytes.Buffer
+	opts.Colorize = false
+	opts.Output = &out
+	opts.RelativePaths = testdataDir(t)
+	analysistest.Run(t, testdataDir(t), NewWithOptions(opts), pattern)
+	if opts.SARIFOut != "" {
+		data, err := os.ReadFile(opts.SARIFOut)
+		if err != nil {
+			t.Fatalf("Failed to read SARIF log: %v", err)
+		}
+		out.Write(data)
+	}
+
+	path := filepath.Join("testdata", "golden", golden)
+	if *update {
+		if err := os.WriteFile(path, out.Bytes(), 0o600); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+	if !bytes.Equal(out.Bytes(), want) {
+		t.Errorf("Output differs from %s (run with -update to rewrite it):\n got %s\nwant %s", path, out.Bytes(), want)
+	}
+}
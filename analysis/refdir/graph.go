@@ -0,0 +1,163 @@
+package refdir
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// graphColors are the DOT colors of references by the severity of their finding.
+var graphColors = map[Severity]string{
+	SeverityError:   "red",
+	SeverityWarning: "orange",
+	SeverityOk:      "green",
+}
+
+// graphNode is a package-scope declaration in the -graph-out file.
+type graphNode struct {
+	pkg  string
+	name string
+}
+
+// graphEdge is a reference from within one declaration to another.
+type graphEdge struct {
+	from graphNode
+	to   graphNode
+}
+
+// declRange is the extent of a package-scope declaration in a file.
+type declRange struct {
+	pos  token.Pos
+	end  token.Pos
+	node graphNode
+}
+
+// graphWriter accumulates the declarations and references of all packages of a run and
+// rewrites the -graph-out file with the full graph each time a package is added.
+type graphWriter struct {
+	mu    sync.Mutex
+	nodes map[graphNode]bool
+	// edges holds the most severe finding of the references along each edge.
+	edges map[graphEdge]Severity
+}
+
+// add adds the package-scope declarations of pass and the references between them found in
+// findings. References reported as info, as well as those within a single declaration or
+// to other packages, are left out.
+func (w *graphWriter) add(path string, pass *analysis.Pass, findings []Finding) error {
+	decls := packageDecls(pass)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.nodes == nil {
+		w.nodes = make(map[graphNode]bool)
+		w.edges = make(map[graphEdge]Severity)
+	}
+	for _, d := range decls {
+		w.nodes[d.node] = true
+	}
+	for _, f := range findings {
+		if f.Kind == "" || graphColors[f.Severity] == "" {
+			continue
+		}
+		from, ok := declContaining(decls, f.Pos)
+		if !ok {
+			continue
+		}
+		to, ok := declContaining(decls, f.DefPos)
+		if !ok || from == to {
+			continue
+		}
+		edge := graphEdge{from: from, to: to}
+		if prev, ok := w.edges[edge]; !ok || slices.Index(Severities, f.Severity) < slices.Index(Severities, prev) {
+			w.edges[edge] = f.Severity
+		}
+	}
+	return writeGraph(path, w.nodes, w.edges)
+}
+
+// packageDecls returns the package-scope declarations of pass, sorted by position. A
+// declaration with several names, as in var a, b = f(), is a single node.
+func packageDecls(pass *analysis.Pass) []declRange {
+	pkg := pass.Pkg.Path()
+	var decls []declRange
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				decls = append(decls, declRange{pos: decl.Pos(), end: decl.End(), node: graphNode{pkg: pkg, name: funcDeclName(decl)}})
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					var names []string
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						names = append(names, spec.Name.Name)
+					case *ast.ValueSpec:
+						for _, name := range spec.Names {
+							if name.Name != "_" {
+								names = append(names, name.Name)
+							}
+						}
+					}
+					if len(names) > 0 {
+						decls = append(decls, declRange{pos: spec.Pos(), end: spec.End(), node: graphNode{pkg: pkg, name: strings.Join(names, ", ")}})
+					}
+				}
+			}
+		}
+	}
+	slices.SortFunc(decls, func(a, b declRange) int { return cmp.Compare(a.pos, b.pos) })
+	return decls
+}
+
+// declContaining returns the declaration of decls, sorted by position, that contains pos.
+func declContaining(decls []declRange, pos token.Pos) (graphNode, bool) {
+	i := sort.Search(len(decls), func(i int) bool { return decls[i].pos > pos }) - 1
+	if i < 0 || pos >= decls[i].end {
+		return graphNode{}, false
+	}
+	return decls[i].node, true
+}
+
+// writeGraph writes nodes and edges to path as a GraphViz DOT digraph with a cluster per
+// package, in a stable order.
+func writeGraph(path string, nodes map[graphNode]bool, edges map[graphEdge]Severity) error {
+	compareNodes := func(a, b graphNode) int {
+		return cmp.Or(strings.Compare(a.pkg, b.pkg), strings.Compare(a.name, b.name))
+	}
+	id := func(n graphNode) string { return strconv.Quote(n.pkg + "." + n.name) }
+
+	var buf bytes.Buffer
+	buf.WriteString("digraph refdir {\n\tnode [shape=box];\n")
+	sorted := slices.SortedFunc(maps.Keys(nodes), compareNodes)
+	for i, n := range sorted {
+		if i == 0 || n.pkg != sorted[i-1].pkg {
+			if i > 0 {
+				buf.WriteString("\t}\n")
+			}
+			fmt.Fprintf(&buf, "\tsubgraph %s {\n\t\tlabel = %s;\n", strconv.Quote("cluster_"+n.pkg), strconv.Quote(n.pkg))
+		}
+		fmt.Fprintf(&buf, "\t\t%s [label=%s];\n", id(n), strconv.Quote(n.name))
+	}
+	if len(sorted) > 0 {
+		buf.WriteString("\t}\n")
+	}
+	for _, e := range slices.SortedFunc(maps.Keys(edges), func(a, b graphEdge) int {
+		return cmp.Or(compareNodes(a.from, b.from), compareNodes(a.to, b.to))
+	}) {
+		fmt.Fprintf(&buf, "\t%s -> %s [color=%s];\n", id(e.from), id(e.to), graphColors[edges[e]])
+	}
+	buf.WriteString("}\n")
+	return os.WriteFile(path, buf.Bytes(), 0o600)
+}
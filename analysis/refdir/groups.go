@@ -0,0 +1,45 @@
+package refdir
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// A lineSpan is an inclusive range of lines.
+type lineSpan struct {
+	start, end int
+}
+
+// declGroups returns the declaration groups of file for -group-tolerance: runs of top-level
+// declarations, doc comments included, that are not separated by blank lines. A
+// parenthesized const, var or type block is always within a single group.
+func declGroups(tf *token.File, file *ast.File) []lineSpan {
+	var groups []lineSpan
+	for _, decl := range file.Decls {
+		start, _ := declLines(tf, decl)
+		span := lineSpan{start: tf.Line(start), end: tf.Line(decl.End())}
+		if n := len(groups); n > 0 && groups[n-1].end+1 >= span.start {
+			groups[n-1].end = span.end
+			continue
+		}
+		groups = append(groups, span)
+	}
+	return groups
+}
+
+// inSameConstBlock reports whether a and b are within the same parenthesized const
+// declaration of file.
+func inSameConstBlock(file *ast.File, a, b token.Pos) bool {
+	decl, ok := enclosingDecl(file, a).(*ast.GenDecl)
+	return ok && decl.Tok == token.CONST && decl.Lparen.IsValid() && decl.Pos() <= b && b < decl.End()
+}
+
+// sameGroup reports whether lines a and b are within the same group.
+func sameGroup(groups []lineSpan, a, b int) bool {
+	for _, g := range groups {
+		if g.start <= a && a <= g.end {
+			return g.start <= b && b <= g.end
+		}
+	}
+	return false
+}
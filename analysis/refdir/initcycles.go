@@ -0,0 +1,126 @@
+package refdir
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// varInitCycles returns the cycles among package-scope vars, each sorted by position.
+//
+// The graph follows var initializers and function bodies like the compiler's
+// initialization order does, and additionally follows interface method calls to every
+// package method that may implement them. Cycles the compiler accepts are therefore
+// the ones through dynamic calls, where a var may be read before it is initialized.
+func varInitCycles(pass *analysis.Pass) [][]*types.Var {
+	graph := make(map[types.Object][]types.Object)
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if fn, ok := pass.TypesInfo.Defs[decl.Name].(*types.Func); ok && decl.Body != nil {
+					graph[fn] = initDeps(pass, decl.Body)
+				}
+			case *ast.GenDecl:
+				if decl.Tok != token.VAR {
+					continue
+				}
+				for _, spec := range decl.Specs {
+					vs, _ := spec.(*ast.ValueSpec)
+					for i, name := range vs.Names {
+						v, ok := pass.TypesInfo.Defs[name].(*types.Var)
+						if !ok {
+							continue
+						}
+						switch {
+						case len(vs.Values) == len(vs.Names):
+							graph[v] = initDeps(pass, vs.Values[i])
+						case len(vs.Values) == 1:
+							// All names are assigned from a single multi-value expression.
+							graph[v] = initDeps(pass, vs.Values[0])
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// A var is in a cycle if its component has other members, vars or functions.
+	members := make(map[int][]*types.Var)
+	sizes := make(map[int]int)
+	for obj, group := range stronglyConnected(graph) {
+		sizes[group]++
+		if v, ok := obj.(*types.Var); ok {
+			members[group] = append(members[group], v)
+		}
+	}
+
+	var cycles [][]*types.Var
+	for group, vars := range members {
+		if sizes[group] < 2 {
+			continue
+		}
+		sort.Slice(vars, func(i, j int) bool { return vars[i].Pos() < vars[j].Pos() })
+		cycles = append(cycles, vars)
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0].Pos() < cycles[j][0].Pos() })
+	return cycles
+}
+
+// initDeps returns the package-scope vars, functions and methods referenced in node.
+// Interface method calls refer to every package method that may implement them.
+func initDeps(pass *analysis.Pass, node ast.Node) []types.Object {
+	var deps []types.Object
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			sel := pass.TypesInfo.Selections[n]
+			if sel == nil || sel.Kind() == types.FieldVal {
+				break
+			}
+			if iface, ok := sel.Recv().Underlying().(*types.Interface); ok {
+				deps = append(deps, implementations(pass, iface, sel.Obj().Name())...)
+			}
+		case *ast.Ident:
+			switch obj := pass.TypesInfo.Uses[n].(type) {
+			case *types.Var:
+				if obj.Pkg() == pass.Pkg && obj.Parent() == pass.Pkg.Scope() {
+					deps = append(deps, obj)
+				}
+			case *types.Func:
+				if obj.Pkg() == pass.Pkg {
+					deps = append(deps, obj.Origin())
+				}
+			}
+		}
+		return true
+	})
+	return deps
+}
+
+// implementations returns the methods named name of the package types implementing iface.
+func implementations(pass *analysis.Pass, iface *types.Interface, name string) []types.Object {
+	var methods []types.Object
+	scope := pass.Pkg.Scope()
+	for _, typeName := range scope.Names() {
+		tn, ok := scope.Lookup(typeName).(*types.TypeName)
+		if !ok || tn.IsAlias() || types.IsInterface(tn.Type()) {
+			continue
+		}
+		if named, ok := tn.Type().(*types.Named); !ok || named.TypeParams().Len() > 0 {
+			// Uninstantiated generic types have no well-defined method set.
+			continue
+		}
+		ptr := types.NewPointer(tn.Type())
+		if !types.Implements(tn.Type(), iface) && !types.Implements(ptr, iface) {
+			continue
+		}
+		if method, _, _ := types.LookupFieldOrMethod(ptr, false, pass.Pkg, name); method != nil {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
@@ -0,0 +1,45 @@
+package refdir
+
+import (
+	"fmt"
+	"slices"
+)
+
+// String returns the name of the kind. With Set, it makes *RefKind a flag.Value.
+func (k RefKind) String() string { return string(k) }
+
+// Set sets k to the kind named s, which must be one of RefKinds.
+func (k *RefKind) Set(s string) error { return k.UnmarshalText([]byte(s)) }
+
+// MarshalText returns the name of the kind, as used in flags and config files.
+func (k RefKind) MarshalText() ([]byte, error) { return []byte(k), nil }
+
+// UnmarshalText sets k to the kind named text, which must be one of RefKinds. Config files
+// and baselines are validated through it when decoded.
+func (k *RefKind) UnmarshalText(text []byte) error {
+	kind := RefKind(text)
+	if !slices.Contains(RefKinds, kind) {
+		return fmt.Errorf("invalid kind %q, must be one of %v", kind, RefKinds)
+	}
+	*k = kind
+	return nil
+}
+
+// String returns the name of the direction. With Set, it makes *Direction a flag.Value.
+func (d Direction) String() string { return string(d) }
+
+// Set sets d to the direction named s, which must be one of Directions.
+func (d *Direction) Set(s string) error { return d.UnmarshalText([]byte(s)) }
+
+// MarshalText returns the name of the direction, as used in flags and config files.
+func (d Direction) MarshalText() ([]byte, error) { return []byte(d), nil }
+
+// UnmarshalText sets d to the direction named text, which must be one of Directions.
+func (d *Direction) UnmarshalText(text []byte) error {
+	dir := Direction(text)
+	if !slices.Contains(Directions, dir) {
+		return fmt.Errorf("invalid direction %q, must be one of %v", dir, Directions)
+	}
+	*d = dir
+	return nil
+}
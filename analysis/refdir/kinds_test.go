@@ -0,0 +1,82 @@
+package refdir
+
+import (
+	"encoding/json"
+	"flag"
+	"maps"
+	"strings"
+	"testing"
+
+	"go.yaml.in/yaml/v3"
+)
+
+func TestRefKindDirectionText(t *testing.T) {
+	order := maps.Clone(RefOrder)
+	order[Label] = Either
+
+	data, err := json.Marshal(order)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	var fromJSON map[RefKind]Direction
+	if err := json.Unmarshal(data, &fromJSON); err != nil {
+		t.Fatalf("Failed to unmarshal JSON: %v", err)
+	}
+	if !maps.Equal(fromJSON, order) {
+		t.Errorf("JSON round trip changed the directions:\n got %v\nwant %v", fromJSON, order)
+	}
+
+	data, err = yaml.Marshal(order)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	var fromYAML map[RefKind]Direction
+	if err := yaml.Unmarshal(data, &fromYAML); err != nil {
+		t.Fatalf("Failed to unmarshal YAML: %v", err)
+	}
+	if !maps.Equal(fromYAML, order) {
+		t.Errorf("YAML round trip changed the directions:\n got %v\nwant %v", fromYAML, order)
+	}
+}
+
+func TestRefKindDirectionTextInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		data string
+		want string
+	}{
+		{name: "json kind", data: `{"funcs": "down"}`, want: `invalid kind "funcs"`},
+		{name: "json direction", data: `{"func": "sideways"}`, want: `invalid direction "sideways"`},
+		{name: "yaml kind", data: "funcs: down\n", want: `invalid kind "funcs"`},
+		{name: "yaml direction", data: "func: sideways\n", want: `invalid direction "sideways"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var order map[RefKind]Direction
+			var err error
+			if strings.HasPrefix(tc.name, "json") {
+				err = json.Unmarshal([]byte(tc.data), &order)
+			} else {
+				err = yaml.Unmarshal([]byte(tc.data), &order)
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Expected an error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestRefKindDirectionFlags(t *testing.T) {
+	kind, dir := Func, Down
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&kind, "kind", "")
+	fs.Var(&dir, "dir", "")
+	if err := fs.Parse([]string{"-kind=ifacetype", "-dir=either"}); err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
+	if kind != IfaceType || dir != Either {
+		t.Errorf("Unexpected flag values %s and %s", kind, dir)
+	}
+	if err := dir.Set("sideways"); err == nil || dir != Either {
+		t.Errorf("Expected an invalid direction to be rejected and leave %s, got %v", dir, err)
+	}
+}
@@ -0,0 +1,58 @@
+package refdir
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// A LineRange is an inclusive range of lines of a file, for -only-lines.
+type LineRange struct {
+	Start, End int
+}
+
+// parseLineRanges adds the ranges listed in s, as in "a.go:3-9,b.go:12", to ranges, keyed
+// by absolute file name. A single line stands for a range of one line.
+func parseLineRanges(s string, ranges map[string][]LineRange) error {
+	for item := range strings.SplitSeq(s, ",") {
+		item = strings.TrimSpace(item)
+		// Split at the last colon, as file names may contain colons, e.g. on Windows.
+		i := strings.LastIndex(item, ":")
+		if i <= 0 {
+			return fmt.Errorf("invalid range %q, must be file:start-end", item)
+		}
+		file, lines := item[:i], item[i+1:]
+		startText, endText, isRange := strings.Cut(lines, "-")
+		if !isRange {
+			endText = startText
+		}
+		start, err := strconv.Atoi(startText)
+		if err != nil {
+			return fmt.Errorf("invalid range %q: %w", item, err)
+		}
+		end, err := strconv.Atoi(endText)
+		if err != nil {
+			return fmt.Errorf("invalid range %q: %w", item, err)
+		}
+		if start < 1 || end < start {
+			return fmt.Errorf("invalid range %q, lines must be positive and in order", item)
+		}
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		ranges[abs] = append(ranges[abs], LineRange{Start: start, End: end})
+	}
+	return nil
+}
+
+// inLineRanges reports whether line is within one of ranges.
+func inLineRanges(ranges []LineRange, line int) bool {
+	for _, r := range ranges {
+		if r.Start <= line && line <= r.End {
+			return true
+		}
+	}
+	return false
+}
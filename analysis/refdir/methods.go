@@ -0,0 +1,89 @@
+package refdir
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// misplacedMethod is a method declared above its receiver type in the same file, or apart
+// from the other methods of its receiver type.
+type misplacedMethod struct {
+	decl *ast.FuncDecl
+	recv *types.TypeName
+	// prev is the previous method of recv in the file, for -methods-grouped.
+	prev *ast.FuncDecl
+}
+
+// methodsBeforeType returns the methods of the package declared above their receiver type
+// in the same file, for -methods-after-type.
+func methodsBeforeType(pass *analysis.Pass) []misplacedMethod {
+	var misplaced []misplacedMethod
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+			tn := methodRecv(pass, fd)
+			if tn == nil || pass.Fset.File(tn.Pos()) != pass.Fset.File(fd.Pos()) {
+				continue
+			}
+			if fd.Pos() < tn.Pos() {
+				misplaced = append(misplaced, misplacedMethod{decl: fd, recv: tn})
+			}
+		}
+	}
+	return misplaced
+}
+
+// ungroupedMethods returns, for each receiver type and file, the first method separated
+// from the previous method of the type in the file by other declarations, for
+// -methods-grouped.
+func ungroupedMethods(pass *analysis.Pass) []misplacedMethod {
+	var misplaced []misplacedMethod
+	for _, file := range pass.Files {
+		// Index in file.Decls of the last method of each receiver type.
+		last := make(map[*types.TypeName]int)
+		reported := make(map[*types.TypeName]bool)
+		for i, decl := range file.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+			tn := methodRecv(pass, fd)
+			if tn == nil {
+				continue
+			}
+			if j, ok := last[tn]; ok && j != i-1 && !reported[tn] {
+				prev, _ := file.Decls[j].(*ast.FuncDecl)
+				misplaced = append(misplaced, misplacedMethod{decl: fd, recv: tn, prev: prev})
+				reported[tn] = true
+			}
+			last[tn] = i
+		}
+	}
+	return misplaced
+}
+
+// methodRecv returns the receiver type of the method declared by fd if it is a named type of
+// the package, or nil.
+func methodRecv(pass *analysis.Pass, fd *ast.FuncDecl) *types.TypeName {
+	if fd.Recv == nil {
+		return nil
+	}
+	fn, ok := pass.TypesInfo.Defs[fd.Name].(*types.Func)
+	if !ok {
+		return nil
+	}
+	recv := fn.Signature().Recv()
+	if recv == nil {
+		return nil
+	}
+	named, ok := derefRecv(recv.Type()).(*types.Named)
+	if !ok || named.Obj().Pkg() != pass.Pkg {
+		return nil
+	}
+	return named.Obj()
+}
@@ -1,82 +1,317 @@
 package refdir
 
 import (
+	"bufio"
+	"cmp"
+	"fmt"
 	"go/token"
+	"io"
+	"os"
+	"slices"
 	"sort"
+	"sync"
+	"sync/atomic"
 
 	"github.com/ppipada/refdir/analysis/refdir/color"
+	"golang.org/x/term"
 	"golang.org/x/tools/go/analysis"
 )
 
+// Severity classifies a Finding.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityOk      Severity = "ok"
+	SeverityInfo    Severity = "info"
+)
+
+// ReportSeverities are the severities an ordering error of a RefKind can be reported with.
+var ReportSeverities = []Severity{
+	SeverityError,
+	SeverityWarning,
+	SeverityInfo,
+}
+
+// Severities lists all severities, from the most to the least severe.
+var Severities = []Severity{
+	SeverityError,
+	SeverityWarning,
+	SeverityOk,
+	SeverityInfo,
+}
+
+// A Finding is a single message produced while checking a package.
+// DefPos, Kind, Direction and Name are empty for messages that are not about a reference.
+// Fixes are only set on errors, and only when suggested fixes are enabled.
+type Finding struct {
+	Pos       token.Pos
+	DefPos    token.Pos
+	Kind      RefKind
+	Direction Direction
+	Name      string
+	Severity  Severity
+	Message   string
+	Fixes     []analysis.SuggestedFix
+}
+
 type Printer interface {
-	Error(p token.Pos, s string)
-	Info(p token.Pos, s string)
-	Ok(p token.Pos, s string)
+	Error(f Finding)
+	Warn(f Finding)
+	Info(f Finding)
+	Ok(f Finding)
 	Flush()
 }
 
+// syncWriter makes each Write call atomic with respect to other callers.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// stdout and stderr serialize writes from printers flushing concurrently for different packages.
+var (
+	stdout io.Writer = &syncWriter{w: os.Stdout}
+	stderr io.Writer = &syncWriter{w: os.Stderr}
+)
+
+// isTerminal reports whether w writes to a terminal.
+func isTerminal(w io.Writer) bool {
+	if s, ok := w.(*syncWriter); ok {
+		w = s.w
+	}
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// PrintTo passes f to the method of p matching its severity.
+func (f Finding) PrintTo(p Printer) {
+	switch f.Severity {
+	case SeverityError:
+		p.Error(f)
+	case SeverityWarning:
+		p.Warn(f)
+	case SeverityOk:
+		p.Ok(f)
+	case SeverityInfo:
+		p.Info(f)
+	}
+}
+
 type SimplePrinter struct {
 	Pass *analysis.Pass
 }
 
-func (c SimplePrinter) Error(p token.Pos, s string) { c.Pass.Reportf(p, "%s", s) }
+func (c SimplePrinter) Error(f Finding) {
+	c.Pass.Report(analysis.Diagnostic{Pos: f.Pos, Message: f.Message, SuggestedFixes: f.Fixes})
+}
+
+func (c SimplePrinter) Warn(f Finding) {
+	c.Pass.Report(analysis.Diagnostic{Pos: f.Pos, Message: f.Message, SuggestedFixes: f.Fixes})
+}
 
-func (c SimplePrinter) Info(p token.Pos, s string) { c.Pass.Reportf(p, "%s", s) }
+func (c SimplePrinter) Info(f Finding) { c.Pass.Reportf(f.Pos, "%s", f.Message) }
 
-func (c SimplePrinter) Ok(p token.Pos, s string) { c.Pass.Reportf(p, "%s", s) }
+func (c SimplePrinter) Ok(f Finding) { c.Pass.Reportf(f.Pos, "%s", f.Message) }
 
 func (c SimplePrinter) Flush() {}
 
+// DiscardPrinter prints nothing, for -quiet. Errors still count toward the ErrorCounter of
+// the options, which are counted before printing.
+type DiscardPrinter struct{}
+
+func (DiscardPrinter) Error(Finding) {}
+
+func (DiscardPrinter) Warn(Finding) {}
+
+func (DiscardPrinter) Info(Finding) {}
+
+func (DiscardPrinter) Ok(Finding) {}
+
+func (DiscardPrinter) Flush() {}
+
+// VerbosePrinter drops info and ok findings unless Verbose is set. When it is set and Fset
+// is not nil, the messages of findings about a reference, those with a valid DefPos, are
+// prefixed with the positions of the reference and its definition, as in
+// "ref@a.go:3:2 -> def@a.go:9:6: func reference f is ...". File names are absolute
+// unless BaseDir is set, in which case they are relative to it.
 type VerbosePrinter struct {
 	Verbose bool
+	Fset    *token.FileSet
+	BaseDir string
 	Printer Printer
 }
 
-func (c VerbosePrinter) Error(p token.Pos, s string) { c.Printer.Error(p, s) }
+func (c VerbosePrinter) Error(f Finding) { c.Printer.Error(c.withPositions(f)) }
+
+func (c VerbosePrinter) Warn(f Finding) { c.Printer.Warn(c.withPositions(f)) }
 
-func (c VerbosePrinter) Info(p token.Pos, s string) {
+func (c VerbosePrinter) Info(f Finding) {
 	if c.Verbose {
-		c.Printer.Info(p, s)
+		c.Printer.Info(c.withPositions(f))
 	}
 }
 
-func (c VerbosePrinter) Ok(p token.Pos, s string) {
+func (c VerbosePrinter) Ok(f Finding) {
 	if c.Verbose {
-		c.Printer.Ok(p, s)
+		c.Printer.Ok(c.withPositions(f))
 	}
 }
 
-func (c VerbosePrinter) Flush() {}
+func (c VerbosePrinter) Flush() { c.Printer.Flush() }
+
+func (c VerbosePrinter) withPositions(f Finding) Finding {
+	if !c.Verbose || c.Fset == nil || !f.Pos.IsValid() || !f.DefPos.IsValid() {
+		return f
+	}
+	f.Message = "ref@" + c.position(f.Pos) + " -> def@" + c.position(f.DefPos) + ": " + f.Message
+	return f
+}
+
+func (c VerbosePrinter) position(pos token.Pos) string {
+	p := c.Fset.Position(pos)
+	p.Filename = relativePath(c.BaseDir, p)
+	return p.String()
+}
+
+// FilterPrinter drops findings less severe than Min, in the order of Severities.
+// The zero Min keeps all findings.
+type FilterPrinter struct {
+	Min     Severity
+	Printer Printer
+}
+
+func (c FilterPrinter) Error(f Finding) {
+	if c.keeps(SeverityError) {
+		c.Printer.Error(f)
+	}
+}
+
+func (c FilterPrinter) Warn(f Finding) {
+	if c.keeps(SeverityWarning) {
+		c.Printer.Warn(f)
+	}
+}
+
+func (c FilterPrinter) Info(f Finding) {
+	if c.keeps(SeverityInfo) {
+		c.Printer.Info(f)
+	}
+}
+
+func (c FilterPrinter) Ok(f Finding) {
+	if c.keeps(SeverityOk) {
+		c.Printer.Ok(f)
+	}
+}
+
+func (c FilterPrinter) Flush() { c.Printer.Flush() }
+
+func (c FilterPrinter) keeps(s Severity) bool {
+	return c.Min == "" || slices.Index(Severities, s) <= slices.Index(Severities, c.Min)
+}
 
 type ColorPrinter struct {
-	ColorError color.Color
-	ColorInfo  color.Color
-	ColorOk    color.Color
-	Pass       *analysis.Pass
+	ColorError   color.Color
+	ColorWarning color.Color
+	ColorInfo    color.Color
+	ColorOk      color.Color
+	Pass         *analysis.Pass
 }
 
-func (c ColorPrinter) Error(p token.Pos, s string) {
-	c.Pass.Reportf(p, "%s", color.Colorize(c.ColorError, s))
+func (c ColorPrinter) Error(f Finding) {
+	c.Pass.Report(analysis.Diagnostic{
+		Pos:            f.Pos,
+		Message:        c.colorize(SeverityError, f.Message),
+		SuggestedFixes: f.Fixes,
+	})
+}
+
+func (c ColorPrinter) Warn(f Finding) {
+	c.Pass.Report(analysis.Diagnostic{
+		Pos:            f.Pos,
+		Message:        c.colorize(SeverityWarning, f.Message),
+		SuggestedFixes: f.Fixes,
+	})
 }
 
-func (c ColorPrinter) Info(p token.Pos, s string) {
-	c.Pass.Reportf(p, "%s", color.Colorize(c.ColorInfo, s))
+func (c ColorPrinter) Info(f Finding) {
+	c.Pass.Reportf(f.Pos, "%s", c.colorize(SeverityInfo, f.Message))
 }
 
-func (c ColorPrinter) Ok(p token.Pos, s string) {
-	c.Pass.Reportf(p, "%s", color.Colorize(c.ColorOk, s))
+func (c ColorPrinter) Ok(f Finding) {
+	c.Pass.Reportf(f.Pos, "%s", c.colorize(SeverityOk, f.Message))
 }
 
 func (c ColorPrinter) Flush() {}
 
-// Function call at position.
+// colorize colors message with the color of severity.
+func (c ColorPrinter) colorize(severity Severity, message string) string {
+	switch severity {
+	case SeverityError:
+		return color.Colorize(c.ColorError, message)
+	case SeverityWarning:
+		return color.Colorize(c.ColorWarning, message)
+	case SeverityInfo:
+		return color.Colorize(c.ColorInfo, message)
+	default:
+		return color.Colorize(c.ColorOk, message)
+	}
+}
+
+// WriterPrinter writes each finding to Writer on its own line, as "position: message".
+// If Writer is a *bufio.Writer, Flush flushes it.
+type WriterPrinter struct {
+	Fset     *token.FileSet
+	Writer   io.Writer
+	Colorize bool
+}
+
+func (c WriterPrinter) Error(f Finding) { c.write(f, color.Red) }
+
+func (c WriterPrinter) Warn(f Finding) { c.write(f, color.Yellow) }
+
+func (c WriterPrinter) Info(f Finding) { c.write(f, color.Gray) }
+
+func (c WriterPrinter) Ok(f Finding) { c.write(f, color.Green) }
+
+func (c WriterPrinter) Flush() {
+	if w, ok := c.Writer.(*bufio.Writer); ok {
+		_ = w.Flush()
+	}
+}
+
+func (c WriterPrinter) write(f Finding, col color.Color) {
+	message := f.Message
+	if c.Colorize {
+		message = color.Colorize(col, message)
+	}
+	_, _ = fmt.Fprintf(c.Writer, "%s: %s\n", c.Fset.Position(f.Pos), message)
+}
+
+// printKey is a finding with the severity it is printed with, as ordered by comparePrints.
+type printKey struct {
+	finding  Finding
+	severity Severity
+}
+
+// Function call printing a finding with a severity.
 type pcall struct {
-	p token.Pos
+	printKey
 	f func()
 }
 
 // SortedPrinter defers printin until Flush is called.
-// Sorts print calls by line number of position.
+// Sorts print calls by line and column of position, then by severity in the order of
+// Severities, kind and message, so findings at the same position print in the same order
+// on every run.
 type SortedPrinter struct {
 	Printer Printer
 	Pass    *analysis.Pass
@@ -84,24 +319,142 @@ type SortedPrinter struct {
 }
 
 func (c *SortedPrinter) Flush() {
-	sort.Slice(c.prints, func(i, j int) bool {
-		iln := c.Pass.Fset.Position(c.prints[i].p).Line
-		jln := c.Pass.Fset.Position(c.prints[j].p).Line
-		return iln < jln
+	sort.SliceStable(c.prints, func(i, j int) bool {
+		a, b := c.prints[i], c.prints[j]
+		return comparePrints(a.printKey, b.printKey, c.Pass.Fset.Position(a.finding.Pos), c.Pass.Fset.Position(b.finding.Pos)) < 0
 	})
 	for _, pc := range c.prints {
 		pc.f()
 	}
+	c.Printer.Flush()
+}
+
+func (c *SortedPrinter) Error(f Finding) {
+	c.prints = append(c.prints, pcall{printKey: printKey{finding: f, severity: SeverityError}, f: func() { c.Printer.Error(f) }})
+}
+
+func (c *SortedPrinter) Warn(f Finding) {
+	c.prints = append(c.prints, pcall{printKey: printKey{finding: f, severity: SeverityWarning}, f: func() { c.Printer.Warn(f) }})
+}
+
+func (c *SortedPrinter) Info(f Finding) {
+	c.prints = append(c.prints, pcall{printKey: printKey{finding: f, severity: SeverityInfo}, f: func() { c.Printer.Info(f) }})
+}
+
+func (c *SortedPrinter) Ok(f Finding) {
+	c.prints = append(c.prints, pcall{printKey: printKey{finding: f, severity: SeverityOk}, f: func() { c.Printer.Ok(f) }})
+}
+
+// comparePrints orders printed findings at apos and bpos by line and column, then by
+// severity in the order of Severities, kind and message.
+func comparePrints(a, b printKey, apos, bpos token.Position) int {
+	return cmp.Or(
+		cmp.Compare(apos.Line, bpos.Line),
+		cmp.Compare(apos.Column, bpos.Column),
+		cmp.Compare(slices.Index(Severities, a.severity), slices.Index(Severities, b.severity)),
+		cmp.Compare(a.finding.Kind, b.finding.Kind),
+		cmp.Compare(a.finding.Message, b.finding.Message),
+	)
+}
+
+// dedupKey identifies a finding for DedupPrinter.
+type dedupKey struct {
+	pos      token.Pos
+	severity Severity
+	message  string
+}
+
+// DedupPrinter drops findings repeating the position, severity and message of an earlier one.
+type DedupPrinter struct {
+	Printer Printer
+	seen    map[dedupKey]bool
+}
+
+func (c *DedupPrinter) Error(f Finding) {
+	if c.first(f, SeverityError) {
+		c.Printer.Error(f)
+	}
+}
+
+func (c *DedupPrinter) Warn(f Finding) {
+	if c.first(f, SeverityWarning) {
+		c.Printer.Warn(f)
+	}
+}
+
+func (c *DedupPrinter) Info(f Finding) {
+	if c.first(f, SeverityInfo) {
+		c.Printer.Info(f)
+	}
+}
+
+func (c *DedupPrinter) Ok(f Finding) {
+	if c.first(f, SeverityOk) {
+		c.Printer.Ok(f)
+	}
+}
+
+func (c *DedupPrinter) Flush() { c.Printer.Flush() }
+
+func (c *DedupPrinter) first(f Finding, severity Severity) bool {
+	key := dedupKey{pos: f.Pos, severity: severity, message: f.Message}
+	if c.seen[key] {
+		return false
+	}
+	if c.seen == nil {
+		c.seen = make(map[dedupKey]bool)
+	}
+	c.seen[key] = true
+	return true
+}
+
+// countingPrinter counts the errors passed to Printer in Counter.
+type countingPrinter struct {
+	Printer Printer
+	Counter *ErrorCounter
 }
 
-func (c *SortedPrinter) Error(p token.Pos, s string) {
-	c.prints = append(c.prints, pcall{p: p, f: func() { c.Printer.Error(p, s) }})
+func (c *countingPrinter) Error(f Finding) {
+	c.Counter.n.Add(1)
+	c.Printer.Error(f)
 }
 
-func (c *SortedPrinter) Info(p token.Pos, s string) {
-	c.prints = append(c.prints, pcall{p: p, f: func() { c.Printer.Info(p, s) }})
+func (c *countingPrinter) Warn(f Finding) { c.Printer.Warn(f) }
+
+func (c *countingPrinter) Info(f Finding) { c.Printer.Info(f) }
+
+func (c *countingPrinter) Ok(f Finding) { c.Printer.Ok(f) }
+
+func (c *countingPrinter) Flush() { c.Printer.Flush() }
+
+// MaxErrorsPrinter passes at most Max errors to Printer, counted in Count so that several
+// printers can share the cap. On Flush it writes the number of errors it dropped to Writer.
+type MaxErrorsPrinter struct {
+	Printer    Printer
+	Writer     io.Writer
+	Title      string
+	Max        int
+	Count      *atomic.Int64
+	suppressed int
 }
 
-func (c *SortedPrinter) Ok(p token.Pos, s string) {
-	c.prints = append(c.prints, pcall{p: p, f: func() { c.Printer.Ok(p, s) }})
+func (c *MaxErrorsPrinter) Error(f Finding) {
+	if c.Count.Add(1) > int64(c.Max) {
+		c.suppressed++
+		return
+	}
+	c.Printer.Error(f)
+}
+
+func (c *MaxErrorsPrinter) Warn(f Finding) { c.Printer.Warn(f) }
+
+func (c *MaxErrorsPrinter) Info(f Finding) { c.Printer.Info(f) }
+
+func (c *MaxErrorsPrinter) Ok(f Finding) { c.Printer.Ok(f) }
+
+func (c *MaxErrorsPrinter) Flush() {
+	c.Printer.Flush()
+	if c.suppressed > 0 {
+		_, _ = fmt.Fprintf(c.Writer, "%s: ... and %d more suppressed (see -max-errors)\n", c.Title, c.suppressed)
+	}
 }
@@ -0,0 +1,82 @@
+package refdir
+
+import (
+	"fmt"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// ContextPrinter appends to the message of each error the Lines source lines around its
+// position, with the line of the position marked, as in
+//
+//	func reference f is after definition
+//	    3 | func g() {
+//	  > 4 | 	f()
+//	    5 | }
+//
+// The file set only knows line offsets, so sources are read with ReadFile, once per file.
+// Errors in files that cannot be read are passed on unchanged.
+type ContextPrinter struct {
+	Fset     *token.FileSet
+	ReadFile func(filename string) ([]byte, error)
+	Lines    int
+	Printer  Printer
+	sources  map[string][]string
+}
+
+func (c *ContextPrinter) Error(f Finding) {
+	if context := c.context(f.Pos); context != "" {
+		f.Message += "\n" + context
+	}
+	c.Printer.Error(f)
+}
+
+func (c *ContextPrinter) Warn(f Finding) { c.Printer.Warn(f) }
+
+func (c *ContextPrinter) Info(f Finding) { c.Printer.Info(f) }
+
+func (c *ContextPrinter) Ok(f Finding) { c.Printer.Ok(f) }
+
+func (c *ContextPrinter) Flush() { c.Printer.Flush() }
+
+// context returns the source lines around pos, or "" if they are not available.
+func (c *ContextPrinter) context(pos token.Pos) string {
+	if !pos.IsValid() {
+		return ""
+	}
+	position := c.Fset.Position(pos)
+	lines := c.source(position.Filename)
+	if position.Line < 1 || position.Line > len(lines) {
+		return ""
+	}
+	first, last := max(position.Line-c.Lines, 1), min(position.Line+c.Lines, len(lines))
+	width := len(strconv.Itoa(last))
+
+	var b strings.Builder
+	for line := first; line <= last; line++ {
+		marker := " "
+		if line == position.Line {
+			marker = ">"
+		}
+		b.WriteString(strings.TrimRight(fmt.Sprintf("  %s %*d | %s", marker, width, line, lines[line-1]), " "))
+		b.WriteByte('\n')
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// source returns the lines of filename, without line terminators, or nil if it cannot be read.
+func (c *ContextPrinter) source(filename string) []string {
+	if lines, ok := c.sources[filename]; ok {
+		return lines
+	}
+	if c.sources == nil {
+		c.sources = make(map[string][]string)
+	}
+	var lines []string
+	if src, err := c.ReadFile(filename); err == nil {
+		lines = strings.Split(strings.ReplaceAll(string(src), "\r\n", "\n"), "\n")
+	}
+	c.sources[filename] = lines
+	return lines
+}
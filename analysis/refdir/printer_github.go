@@ -0,0 +1,61 @@
+package refdir
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// githubEscaper escapes the data of GitHub Actions workflow commands, which are
+// line-oriented.
+var githubEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+
+// githubPropertyEscaper escapes the property values of workflow commands, which are also
+// separated by commas and colons.
+var githubPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+
+// GitHubPrinter writes findings as GitHub Actions workflow commands, which GitHub shows as
+// annotations on the lines of a pull request. Errors become ::error commands, warnings
+// ::warning, and info and ok findings ::notice. The commands of a package are buffered and
+// written to Writer on Flush. File paths are absolute unless BaseDir is set, in which case
+// they are relative to it, as GitHub expects paths relative to the repository root.
+type GitHubPrinter struct {
+	Pass    *analysis.Pass
+	Writer  io.Writer
+	BaseDir string
+	buf     strings.Builder
+}
+
+func (c *GitHubPrinter) Error(f Finding) { c.add(f, "error") }
+
+func (c *GitHubPrinter) Warn(f Finding) { c.add(f, "warning") }
+
+func (c *GitHubPrinter) Info(f Finding) { c.add(f, "notice") }
+
+func (c *GitHubPrinter) Ok(f Finding) { c.add(f, "notice") }
+
+func (c *GitHubPrinter) Flush() {
+	if c.buf.Len() == 0 {
+		return
+	}
+	_, _ = io.WriteString(c.Writer, c.buf.String())
+	c.buf.Reset()
+}
+
+func (c *GitHubPrinter) add(f Finding, command string) {
+	pos := c.Pass.Fset.Position(f.Pos)
+	title := analyzerName
+	if f.Kind != "" {
+		title += " " + string(f.Kind)
+	}
+	fmt.Fprintf(&c.buf, "::%s file=%s,line=%d,col=%d,title=%s::%s\n",
+		command,
+		githubPropertyEscaper.Replace(relativePath(c.BaseDir, pos)),
+		pos.Line,
+		pos.Column,
+		githubPropertyEscaper.Replace(title),
+		githubEscaper.Replace(f.Message),
+	)
+}
@@ -0,0 +1,71 @@
+package refdir
+
+import (
+	"cmp"
+	"fmt"
+	"go/token"
+	"io"
+	"slices"
+	"strings"
+)
+
+// groupedPrint is a finding buffered by GroupedPrinter, with its resolved position.
+type groupedPrint struct {
+	pos token.Position
+	key printKey
+}
+
+// GroupedPrinter writes the findings of a package to Writer on Flush, grouped under a header
+// per file, as in
+//
+//	--- a.go ---
+//	3:2: func reference f is after definition
+//
+// Files are in name order and findings in position order, ties broken like SortedPrinter.
+// File names are absolute unless BaseDir is set, in which case they are relative to it.
+// If Colors is set, messages are colored by severity as it colors diagnostics.
+type GroupedPrinter struct {
+	Fset    *token.FileSet
+	Writer  io.Writer
+	BaseDir string
+	Colors  *ColorPrinter
+	prints  []groupedPrint
+}
+
+func (c *GroupedPrinter) Error(f Finding) { c.add(f, SeverityError) }
+
+func (c *GroupedPrinter) Warn(f Finding) { c.add(f, SeverityWarning) }
+
+func (c *GroupedPrinter) Info(f Finding) { c.add(f, SeverityInfo) }
+
+func (c *GroupedPrinter) Ok(f Finding) { c.add(f, SeverityOk) }
+
+func (c *GroupedPrinter) Flush() {
+	if len(c.prints) == 0 {
+		return
+	}
+	slices.SortStableFunc(c.prints, func(a, b groupedPrint) int {
+		return cmp.Or(strings.Compare(a.pos.Filename, b.pos.Filename), comparePrints(a.key, b.key, a.pos, b.pos))
+	})
+
+	var b strings.Builder
+	for i, g := range c.prints {
+		if i == 0 || g.pos.Filename != c.prints[i-1].pos.Filename {
+			if i > 0 {
+				b.WriteByte('\n')
+			}
+			fmt.Fprintf(&b, "--- %s ---\n", relativePath(c.BaseDir, g.pos))
+		}
+		message := g.key.finding.Message
+		if c.Colors != nil {
+			message = c.Colors.colorize(g.key.severity, message)
+		}
+		fmt.Fprintf(&b, "%d:%d: %s\n", g.pos.Line, g.pos.Column, message)
+	}
+	_, _ = io.WriteString(c.Writer, b.String())
+	c.prints = nil
+}
+
+func (c *GroupedPrinter) add(f Finding, severity Severity) {
+	c.prints = append(c.prints, groupedPrint{pos: c.Fset.Position(f.Pos), key: printKey{finding: f, severity: severity}})
+}
@@ -0,0 +1,87 @@
+package refdir
+
+import (
+	"encoding/json"
+	"go/token"
+	"io"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// jsonFinding is the serialized form of a Finding.
+type jsonFinding struct {
+	File     string  `json:"file"`
+	Line     int     `json:"line"`
+	Column   int     `json:"column"`
+	Kind     RefKind `json:"kind,omitempty"`
+	RefName  string  `json:"refName,omitempty"`
+	Severity string  `json:"severity"`
+	Message  string  `json:"message"`
+}
+
+// JSONPrinter buffers findings and writes them to Writer as a single JSON array on Flush.
+// File paths are absolute unless BaseDir is set, in which case they are relative to it.
+type JSONPrinter struct {
+	Pass     *analysis.Pass
+	Writer   io.Writer
+	BaseDir  string
+	findings []jsonFinding
+}
+
+func (c *JSONPrinter) Error(f Finding) { c.add(f, "error") }
+
+func (c *JSONPrinter) Warn(f Finding) { c.add(f, "warning") }
+
+func (c *JSONPrinter) Info(f Finding) { c.add(f, "info") }
+
+func (c *JSONPrinter) Ok(f Finding) { c.add(f, "ok") }
+
+// Flush writes the buffered findings in position order. Nothing is written if
+// there are none, so packages without findings produce no output.
+func (c *JSONPrinter) Flush() {
+	if len(c.findings) == 0 {
+		return
+	}
+	sort.SliceStable(c.findings, func(i, j int) bool {
+		a, b := c.findings[i], c.findings[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	// Findings only hold strings and ints, so marshaling cannot fail.
+	out, _ := json.Marshal(c.findings)
+	_, _ = c.Writer.Write(append(out, '\n'))
+	c.findings = nil
+}
+
+func (c *JSONPrinter) add(f Finding, severity string) {
+	pos := c.Pass.Fset.Position(f.Pos)
+	c.findings = append(c.findings, jsonFinding{
+		File:     relativePath(c.BaseDir, pos),
+		Line:     pos.Line,
+		Column:   pos.Column,
+		Kind:     f.Kind,
+		RefName:  f.Name,
+		Severity: severity,
+		Message:  f.Message,
+	})
+}
+
+// relativePath returns the file name of pos relative to base.
+// The name is returned unchanged if base is empty or no relative path exists.
+func relativePath(base string, pos token.Position) string {
+	if base == "" {
+		return pos.Filename
+	}
+	rel, err := filepath.Rel(base, pos.Filename)
+	if err != nil {
+		return pos.Filename
+	}
+	return rel
+}
@@ -0,0 +1,213 @@
+package refdir
+
+import (
+	"cmp"
+	"encoding/json"
+	"go/token"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifToolURI = "https://github.com/ppipada/refdir"
+)
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+// sarifWriter accumulates the SARIF results of all packages of a run and rewrites the
+// -sarif-out file with a single log of one run each time a package is added.
+type sarifWriter struct {
+	mu      sync.Mutex
+	results []sarifResult
+}
+
+// SARIFPrinter buffers findings and writes them to Writer as a single SARIF 2.1.0 log on Flush.
+// Only errors and warnings are reported unless IncludeNotes is set, in which case info and ok
+// findings become note-level results.
+// File URIs are absolute unless BaseDir is set, in which case they are relative to it.
+type SARIFPrinter struct {
+	Pass         *analysis.Pass
+	Writer       io.Writer
+	BaseDir      string
+	IncludeNotes bool
+	results      []sarifResult
+	// run, if set, receives the results on Flush instead of Writer.
+	run *sarifWriter
+}
+
+func (c *SARIFPrinter) Error(f Finding) { c.add(f, "error") }
+
+func (c *SARIFPrinter) Warn(f Finding) { c.add(f, "warning") }
+
+func (c *SARIFPrinter) Info(f Finding) {
+	if c.IncludeNotes {
+		c.add(f, "note")
+	}
+}
+
+func (c *SARIFPrinter) Ok(f Finding) {
+	if c.IncludeNotes {
+		c.add(f, "note")
+	}
+}
+
+// Flush writes the buffered results. Nothing is written if there are none,
+// so packages without findings produce no output.
+func (c *SARIFPrinter) Flush() {
+	if c.run != nil {
+		c.run.add(c.results)
+		c.results = nil
+		return
+	}
+	if len(c.results) == 0 {
+		return
+	}
+	_, _ = c.Writer.Write(marshalSARIF(c.results))
+	c.results = nil
+}
+
+func (c *SARIFPrinter) add(f Finding, level string) {
+	pos := c.Pass.Fset.Position(f.Pos)
+	c.results = append(c.results, sarifResult{
+		RuleID:  sarifRuleID(f.Kind),
+		Level:   level,
+		Message: sarifMessage{Text: f.Message},
+		Locations: []sarifLocation{{
+			PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: sarifURI(c.BaseDir, pos)},
+				Region:           sarifRegion{StartLine: pos.Line, StartColumn: pos.Column},
+			},
+		}},
+	})
+}
+
+func (w *sarifWriter) add(results []sarifResult) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.results = append(w.results, results...)
+}
+
+// write writes the results added so far to path, sorted by location, as packages are
+// checked in no particular order.
+func (w *sarifWriter) write(path string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	slices.SortStableFunc(w.results, func(a, b sarifResult) int {
+		la, lb := a.Locations[0].PhysicalLocation, b.Locations[0].PhysicalLocation
+		return cmp.Or(
+			cmp.Compare(la.ArtifactLocation.URI, lb.ArtifactLocation.URI),
+			cmp.Compare(la.Region.StartLine, lb.Region.StartLine),
+			cmp.Compare(la.Region.StartColumn, lb.Region.StartColumn),
+		)
+	})
+	return os.WriteFile(path, marshalSARIF(w.results), 0o600)
+}
+
+// marshalSARIF returns a SARIF log of a single run holding results, ending with a newline.
+func marshalSARIF(results []sarifResult) []byte {
+	// A run without results still lists them, so that code scanning closes old alerts.
+	if results == nil {
+		results = []sarifResult{}
+	}
+	rules := make([]sarifRule, 0, len(RefKinds))
+	for _, kind := range RefKinds {
+		rules = append(rules, sarifRule{
+			ID:               sarifRuleID(kind),
+			ShortDescription: sarifMessage{Text: "Ordering of " + refKindDocs[kind]},
+		})
+	}
+	log := sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           analyzerName,
+				InformationURI: sarifToolURI,
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+	// The log only holds strings and ints, so marshaling cannot fail.
+	out, _ := json.Marshal(log)
+	return append(out, '\n')
+}
+
+// sarifRuleID returns the rule of a kind, or the bare analyzer name for
+// findings that are not about a reference.
+func sarifRuleID(kind RefKind) string {
+	if kind == "" {
+		return analyzerName
+	}
+	return analyzerName + "/" + string(kind)
+}
+
+// sarifURI returns a relative URI when base is set, and an absolute file URI otherwise.
+func sarifURI(base string, pos token.Position) string {
+	path := relativePath(base, pos)
+	if filepath.IsAbs(path) {
+		return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+	}
+	return filepath.ToSlash(path)
+}
@@ -0,0 +1,83 @@
+package refdir
+
+import (
+	"fmt"
+	"go/token"
+	"io"
+	"sort"
+)
+
+// scoredFinding is an error with its ScorePrinter score.
+type scoredFinding struct {
+	f     Finding
+	score float64
+}
+
+// ScorePrinter ranks the errors of a package to help decide what to fix first. The score
+// of an error is the line distance between the reference and its definition times the
+// weight of its kind. Errors across files have a distance of 1. On Flush it writes the Top
+// highest scoring errors to Writer, separately from the findings themselves. File names are
+// absolute unless BaseDir is set, in which case they are relative to it.
+type ScorePrinter struct {
+	Printer Printer
+	Writer  io.Writer
+	Title   string
+	Fset    *token.FileSet
+	BaseDir string
+	Top     int
+	// Weights holds the weight of each kind. Kinds missing from it weigh 1.
+	Weights map[RefKind]float64
+	scored  []scoredFinding
+}
+
+func (c *ScorePrinter) Error(f Finding) {
+	c.scored = append(c.scored, scoredFinding{f: f, score: c.score(f)})
+	c.Printer.Error(f)
+}
+
+func (c *ScorePrinter) Warn(f Finding) { c.Printer.Warn(f) }
+
+func (c *ScorePrinter) Info(f Finding) { c.Printer.Info(f) }
+
+func (c *ScorePrinter) Ok(f Finding) { c.Printer.Ok(f) }
+
+// Flush flushes Printer, then writes a report like
+//
+//	refdir: example.com/pkg: prioritization report, top 2 of 5 errors by score (line distance x kind weight):
+//	  48 a.go:10:2: func reference f is before definition
+//	  12 a.go:3:6: type reference T is before definition
+//
+// Nothing is written when there are no errors.
+func (c *ScorePrinter) Flush() {
+	c.Printer.Flush()
+	if len(c.scored) == 0 {
+		return
+	}
+	sort.SliceStable(c.scored, func(i, j int) bool { return c.scored[i].score > c.scored[j].score })
+	top := min(c.Top, len(c.scored))
+	_, _ = fmt.Fprintf(
+		c.Writer,
+		"%s: prioritization report, top %d of %d errors by score (line distance x kind weight):\n",
+		c.Title,
+		top,
+		len(c.scored),
+	)
+	for _, s := range c.scored[:top] {
+		pos := c.Fset.Position(s.f.Pos)
+		pos.Filename = relativePath(c.BaseDir, pos)
+		_, _ = fmt.Fprintf(c.Writer, "  %g %s: %s\n", s.score, pos, s.f.Message)
+	}
+}
+
+func (c *ScorePrinter) score(f Finding) float64 {
+	weight, ok := c.Weights[f.Kind]
+	if !ok {
+		weight = 1
+	}
+	distance := 1
+	ref, def := c.Fset.Position(f.Pos), c.Fset.Position(f.DefPos)
+	if f.DefPos.IsValid() && ref.Filename == def.Filename {
+		distance = max(ref.Line-def.Line, def.Line-ref.Line)
+	}
+	return float64(distance) * weight
+}
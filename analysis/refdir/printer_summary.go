@@ -0,0 +1,84 @@
+package refdir
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SummaryPrinter counts findings per severity and kind before delegating to Printer,
+// and writes a single summary line to Writer on Flush. If Colors is set, the counts are
+// colored by severity as it colors diagnostics.
+type SummaryPrinter struct {
+	Printer  Printer
+	Writer   io.Writer
+	Title    string
+	Colors   *ColorPrinter
+	errors   map[RefKind]int
+	warnings int
+	ok       int
+	info     int
+}
+
+func (c *SummaryPrinter) Error(f Finding) {
+	if c.errors == nil {
+		c.errors = make(map[RefKind]int)
+	}
+	c.errors[f.Kind]++
+	c.Printer.Error(f)
+}
+
+func (c *SummaryPrinter) Warn(f Finding) {
+	c.warnings++
+	c.Printer.Warn(f)
+}
+
+func (c *SummaryPrinter) Info(f Finding) {
+	c.info++
+	c.Printer.Info(f)
+}
+
+func (c *SummaryPrinter) Ok(f Finding) {
+	c.ok++
+	c.Printer.Ok(f)
+}
+
+// Flush flushes Printer, then writes a line like
+// "refdir: 12 errors (func:7 type:5), 340 ok, 25 info". Warnings are only listed when
+// there are some, as in "refdir: 12 errors (func:7 type:5), 3 warnings, 340 ok, 25 info".
+func (c *SummaryPrinter) Flush() {
+	c.Printer.Flush()
+
+	total := 0
+	var perKind []string
+	for _, kind := range RefKinds {
+		if n := c.errors[kind]; n > 0 {
+			perKind = append(perKind, fmt.Sprintf("%s:%d", kind, n))
+		}
+	}
+	for _, n := range c.errors {
+		total += n
+	}
+	errs := fmt.Sprintf("%d errors", total)
+	if len(perKind) > 0 {
+		errs += " (" + strings.Join(perKind, " ") + ")"
+	}
+	warnings := ""
+	if c.warnings > 0 {
+		warnings = fmt.Sprintf("%d warnings", c.warnings)
+	}
+	ok, info := fmt.Sprintf("%d ok", c.ok), fmt.Sprintf("%d info", c.info)
+	if c.Colors != nil {
+		if total > 0 {
+			errs = c.Colors.colorize(SeverityError, errs)
+		}
+		if warnings != "" {
+			warnings = c.Colors.colorize(SeverityWarning, warnings)
+		}
+		ok, info = c.Colors.colorize(SeverityOk, ok), c.Colors.colorize(SeverityInfo, info)
+	}
+	if warnings != "" {
+		errs += ", " + warnings
+	}
+	_, _ = fmt.Fprintf(c.Writer, "%s: %s, %s, %s\n", c.Title, errs, ok, info)
+}
@@ -0,0 +1,363 @@
+package refdir
+
+import (
+	"bufio"
+	"bytes"
+	"go/token"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ppipada/refdir/analysis/refdir/color"
+	"golang.org/x/tools/go/analysis"
+)
+
+// nopPrinter drops all findings.
+type nopPrinter struct{}
+
+func (nopPrinter) Error(Finding) {}
+
+func (nopPrinter) Warn(Finding) {}
+
+func (nopPrinter) Info(Finding) {}
+
+func (nopPrinter) Ok(Finding) {}
+
+func (nopPrinter) Flush() {}
+
+// recordingPrinter records the messages of all findings.
+type recordingPrinter struct {
+	messages []string
+}
+
+func (r *recordingPrinter) Error(f Finding) { r.messages = append(r.messages, "error: "+f.Message) }
+
+func (r *recordingPrinter) Warn(f Finding) { r.messages = append(r.messages, "warning: "+f.Message) }
+
+func (r *recordingPrinter) Info(f Finding) { r.messages = append(r.messages, "info: "+f.Message) }
+
+func (r *recordingPrinter) Ok(f Finding) { r.messages = append(r.messages, "ok: "+f.Message) }
+
+func (r *recordingPrinter) Flush() {}
+
+func TestDedupPrinter(t *testing.T) {
+	rec := &recordingPrinter{}
+	p := &DedupPrinter{Printer: rec}
+	p.Error(Finding{Pos: 1, Message: "a"})
+	p.Error(Finding{Pos: 1, Message: "a"})
+	p.Info(Finding{Pos: 1, Message: "a"})
+	p.Error(Finding{Pos: 2, Message: "a"})
+	p.Error(Finding{Pos: 1, Message: "b"})
+	p.Flush()
+
+	want := []string{"error: a", "info: a", "error: a", "error: b"}
+	if !slices.Equal(rec.messages, want) {
+		t.Errorf("Unexpected findings:\n got %q\nwant %q", rec.messages, want)
+	}
+}
+
+func TestFilterPrinter(t *testing.T) {
+	for _, tt := range []struct {
+		min  Severity
+		want []string
+	}{
+		{"", []string{"error: e", "warning: w", "ok: o", "info: i"}},
+		{SeverityError, []string{"error: e"}},
+		{SeverityWarning, []string{"error: e", "warning: w"}},
+		{SeverityOk, []string{"error: e", "warning: w", "ok: o"}},
+		{SeverityInfo, []string{"error: e", "warning: w", "ok: o", "info: i"}},
+	} {
+		rec := &recordingPrinter{}
+		p := FilterPrinter{Min: tt.min, Printer: rec}
+		p.Error(Finding{Message: "e"})
+		p.Warn(Finding{Message: "w"})
+		p.Ok(Finding{Message: "o"})
+		p.Info(Finding{Message: "i"})
+		if !slices.Equal(rec.messages, tt.want) {
+			t.Errorf("Unexpected findings with min %q:\n got %q\nwant %q", tt.min, rec.messages, tt.want)
+		}
+	}
+}
+
+func TestWriterPrinter(t *testing.T) {
+	fset := token.NewFileSet()
+	file := fset.AddFile("p.go", -1, 100)
+	file.SetLines([]int{0, 10, 20})
+
+	var out bytes.Buffer
+	w := bufio.NewWriter(&out)
+	p := WriterPrinter{Fset: fset, Writer: w}
+	p.Error(Finding{Pos: file.Pos(12), Message: "func reference f is after definition"})
+	p.Ok(Finding{Pos: file.Pos(3), Message: "100%"})
+	if out.Len() != 0 {
+		t.Fatalf("Expected buffered output before Flush, got %q", out.String())
+	}
+	p.Flush()
+
+	want := "p.go:2:3: func reference f is after definition\np.go:1:4: 100%\n"
+	if got := out.String(); got != want {
+		t.Errorf("Unexpected output:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestSortedPrinterTies(t *testing.T) {
+	fset := token.NewFileSet()
+	file := fset.AddFile("p.go", -1, 100)
+	file.SetLines([]int{0, 10, 20})
+	pos := file.Pos(12)
+
+	rec := &recordingPrinter{}
+	p := &SortedPrinter{Printer: rec, Pass: &analysis.Pass{Fset: fset}}
+	p.Info(Finding{Pos: pos, Kind: Func, Message: "skipping"})
+	p.Error(Finding{Pos: pos, Kind: Var, Message: "var reference b is before definition"})
+	p.Error(Finding{Pos: pos, Kind: Type, Message: "type reference T is before definition"})
+	p.Error(Finding{Pos: pos, Kind: Var, Message: "var reference a is before definition"})
+	p.Ok(Finding{Pos: pos, Kind: Func, Message: "func reference f is after definition"})
+	p.Error(Finding{Pos: file.Pos(3), Kind: Var, Message: "var reference c is before definition"})
+	p.Flush()
+
+	want := []string{
+		"error: var reference c is before definition",
+		"error: type reference T is before definition",
+		"error: var reference a is before definition",
+		"error: var reference b is before definition",
+		"ok: func reference f is after definition",
+		"info: skipping",
+	}
+	if !slices.Equal(rec.messages, want) {
+		t.Errorf("Unexpected findings:\n got %q\nwant %q", rec.messages, want)
+	}
+}
+
+func TestPrintersWarn(t *testing.T) {
+	fset := token.NewFileSet()
+	file := fset.AddFile("p.go", -1, 100)
+	file.SetLines([]int{0, 10, 20})
+	pos := file.Pos(12)
+
+	var diagnostics []string
+	pass := &analysis.Pass{Fset: fset, Report: func(d analysis.Diagnostic) { diagnostics = append(diagnostics, d.Message) }}
+	SimplePrinter{Pass: pass}.Warn(Finding{Pos: pos, Message: "w"})
+	ColorPrinter{Pass: pass, ColorWarning: color.Yellow}.Warn(Finding{Pos: pos, Message: "w"})
+	if want := []string{"w", color.Colorize(color.Yellow, "w")}; !slices.Equal(diagnostics, want) {
+		t.Errorf("Unexpected diagnostics:\n got %q\nwant %q", diagnostics, want)
+	}
+
+	// Warnings are printed without -verbose, like errors.
+	rec := &recordingPrinter{}
+	VerbosePrinter{Fset: fset, Printer: rec}.Warn(Finding{Pos: pos, Message: "w"})
+	if want := []string{"warning: w"}; !slices.Equal(rec.messages, want) {
+		t.Errorf("Unexpected findings:\n got %q\nwant %q", rec.messages, want)
+	}
+
+	// At the same position, warnings sort between errors and ok and info findings.
+	rec = &recordingPrinter{}
+	p := &SortedPrinter{Printer: rec, Pass: pass}
+	p.Info(Finding{Pos: pos, Message: "i"})
+	p.Ok(Finding{Pos: pos, Message: "o"})
+	p.Warn(Finding{Pos: pos, Message: "w"})
+	p.Error(Finding{Pos: pos, Message: "e"})
+	p.Flush()
+	if want := []string{"error: e", "warning: w", "ok: o", "info: i"}; !slices.Equal(rec.messages, want) {
+		t.Errorf("Unexpected findings:\n got %q\nwant %q", rec.messages, want)
+	}
+
+	var out bytes.Buffer
+	WriterPrinter{Fset: fset, Writer: &out, Colorize: true}.Warn(Finding{Pos: pos, Message: "w"})
+	if want := "p.go:2:3: " + color.Colorize(color.Yellow, "w") + "\n"; out.String() != want {
+		t.Errorf("Unexpected output %q, want %q", out.String(), want)
+	}
+}
+
+func TestVerbosePrinterPositions(t *testing.T) {
+	fset := token.NewFileSet()
+	file := fset.AddFile("p.go", -1, 100)
+	file.SetLines([]int{0, 10, 20})
+	ref := Finding{Pos: file.Pos(12), DefPos: file.Pos(21), Message: "func reference f is before definition"}
+
+	rec := &recordingPrinter{}
+	VerbosePrinter{Verbose: true, Fset: fset, Printer: rec}.Error(ref)
+	VerbosePrinter{Verbose: true, Fset: fset, Printer: rec}.Info(Finding{Pos: file.Pos(3), Message: "skipping"})
+	VerbosePrinter{Fset: fset, Printer: rec}.Error(ref)
+
+	want := []string{
+		"error: ref@p.go:2:3 -> def@p.go:3:2: func reference f is before definition",
+		"info: skipping",
+		"error: func reference f is before definition",
+	}
+	if !slices.Equal(rec.messages, want) {
+		t.Errorf("Unexpected findings:\n got %q\nwant %q", rec.messages, want)
+	}
+}
+
+func TestSummaryPrinter(t *testing.T) {
+	var out bytes.Buffer
+	p := &SummaryPrinter{Printer: nopPrinter{}, Writer: &out, Title: "refdir: example"}
+	for _, kind := range []RefKind{Type, Func, Func} {
+		p.Error(Finding{Kind: kind})
+	}
+	p.Ok(Finding{Kind: Func})
+	p.Info(Finding{})
+	p.Flush()
+
+	want := "refdir: example: 3 errors (func:2 type:1), 1 ok, 1 info\n"
+	if got := out.String(); got != want {
+		t.Errorf("Unexpected summary:\n got %q\nwant %q", got, want)
+	}
+
+	out.Reset()
+	p.Colors = &ColorPrinter{ColorError: color.Purple, ColorWarning: color.Yellow, ColorInfo: color.Blue, ColorOk: color.Cyan}
+	p.Warn(Finding{})
+	p.Flush()
+
+	want = "refdir: example: " + color.Colorize(color.Purple, "3 errors (func:2 type:1)") + ", " +
+		color.Colorize(color.Yellow, "1 warnings") + ", " + color.Colorize(color.Cyan, "1 ok") + ", " +
+		color.Colorize(color.Blue, "1 info") + "\n"
+	if got := out.String(); got != want {
+		t.Errorf("Unexpected colored summary:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestMaxErrorsPrinter(t *testing.T) {
+	var out bytes.Buffer
+	var count atomic.Int64
+	first := &recordingPrinter{}
+	second := &recordingPrinter{}
+	p1 := &MaxErrorsPrinter{Printer: first, Writer: &out, Title: "refdir: a", Max: 2, Count: &count}
+	p2 := &MaxErrorsPrinter{Printer: second, Writer: &out, Title: "refdir: b", Max: 2, Count: &count}
+	p1.Error(Finding{Message: "1"})
+	p2.Error(Finding{Message: "2"})
+	p2.Ok(Finding{Message: "3"})
+	p2.Error(Finding{Message: "4"})
+	p1.Error(Finding{Message: "5"})
+	p1.Flush()
+	p2.Flush()
+
+	if want := []string{"error: 1"}; !slices.Equal(first.messages, want) {
+		t.Errorf("Unexpected findings of first printer:\n got %q\nwant %q", first.messages, want)
+	}
+	if want := []string{"error: 2", "ok: 3"}; !slices.Equal(second.messages, want) {
+		t.Errorf("Unexpected findings of second printer:\n got %q\nwant %q", second.messages, want)
+	}
+	want := "refdir: a: ... and 1 more suppressed (see -max-errors)\n" +
+		"refdir: b: ... and 1 more suppressed (see -max-errors)\n"
+	if got := out.String(); got != want {
+		t.Errorf("Unexpected notes:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestSummaryPrinterWarnings(t *testing.T) {
+	var out bytes.Buffer
+	p := &SummaryPrinter{Printer: nopPrinter{}, Writer: &out, Title: "refdir: example"}
+	p.Error(Finding{Kind: Func})
+	p.Warn(Finding{Kind: Var})
+	p.Warn(Finding{Kind: Const})
+	p.Flush()
+
+	want := "refdir: example: 1 errors (func:1), 2 warnings, 0 ok, 0 info\n"
+	if got := out.String(); got != want {
+		t.Errorf("Unexpected summary:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestScorePrinter(t *testing.T) {
+	fset := token.NewFileSet()
+	a := fset.AddFile("a.go", -1, 1000)
+	a.SetLines([]int{0, 10, 20, 30, 40, 50, 60, 70, 80, 90, 100})
+	b := fset.AddFile("b.go", -1, 100)
+
+	var out bytes.Buffer
+	p := &ScorePrinter{
+		Printer: nopPrinter{},
+		Writer:  &out,
+		Title:   "refdir: p",
+		Fset:    fset,
+		Top:     2,
+		Weights: map[RefKind]float64{Type: 4},
+	}
+	p.Error(Finding{Pos: a.Pos(1), DefPos: a.Pos(91), Kind: Func, Message: "far func"})
+	p.Error(Finding{Pos: a.Pos(11), DefPos: a.Pos(41), Kind: Type, Message: "weighted type"})
+	p.Error(Finding{Pos: a.Pos(21), DefPos: b.Pos(1), Kind: Type, Message: "cross-file type"})
+	p.Warn(Finding{Pos: a.Pos(1), DefPos: a.Pos(101), Kind: Func, Message: "warning"})
+	p.Flush()
+
+	want := "refdir: p: prioritization report, top 2 of 3 errors by score (line distance x kind weight):\n" +
+		"  12 a.go:2:2: weighted type\n" +
+		"  9 a.go:1:2: far func\n"
+	if out.String() != want {
+		t.Errorf("Unexpected report:\n got %q\nwant %q", out.String(), want)
+	}
+}
+
+func TestGitHubPrinterEscapes(t *testing.T) {
+	fset := token.NewFileSet()
+	file := fset.AddFile("dir,1/a.go", -1, 100)
+	var out bytes.Buffer
+	p := &GitHubPrinter{Pass: &analysis.Pass{Fset: fset}, Writer: &out}
+	p.Info(Finding{Pos: file.Pos(3), Message: "100% done\nnext line"})
+	p.Flush()
+
+	want := "::notice file=dir%2C1/a.go,line=1,col=4,title=refdir::100%25 done%0Anext line\n"
+	if out.String() != want {
+		t.Errorf("Unexpected output:\n got %q\nwant %q", out.String(), want)
+	}
+}
+
+func TestContextPrinter(t *testing.T) {
+	src := "package p\n\nfunc g() {\n\tf()\n}\n\nfunc f() {}\n"
+	fset := token.NewFileSet()
+	tf := fset.AddFile("a.go", -1, len(src))
+	tf.SetLinesForContent([]byte(src))
+
+	var rec recordingPrinter
+	p := &ContextPrinter{
+		Fset:     fset,
+		ReadFile: func(string) ([]byte, error) { return []byte(src), nil },
+		Lines:    1,
+		Printer:  &rec,
+	}
+	p.Error(Finding{Pos: tf.Pos(strings.Index(src, "f()")), Message: "func reference f is after definition"})
+	p.Error(Finding{Pos: tf.Pos(0), Message: "at the first line"})
+	p.Info(Finding{Pos: tf.Pos(strings.Index(src, "f()")), Message: "skipping"})
+	p.Flush()
+
+	want := []string{
+		"error: func reference f is after definition\n" +
+			"    3 | func g() {\n" +
+			"  > 4 | \tf()\n" +
+			"    5 | }",
+		"error: at the first line\n" +
+			"  > 1 | package p\n" +
+			"    2 |",
+		"info: skipping",
+	}
+	if !slices.Equal(rec.messages, want) {
+		t.Errorf("Unexpected messages:\n got %q\nwant %q", rec.messages, want)
+	}
+}
+
+func TestGroupedPrinter(t *testing.T) {
+	fset := token.NewFileSet()
+	a := fset.AddFile("a.go", -1, 100)
+	a.SetLines([]int{0, 10, 20})
+	b := fset.AddFile("b.go", -1, 100)
+	b.SetLines([]int{0, 10, 20})
+
+	var out bytes.Buffer
+	grouped := &GroupedPrinter{Fset: fset, Writer: &out, Colors: &ColorPrinter{ColorError: color.Red, ColorOk: color.Green}}
+	p := VerbosePrinter{Printer: grouped}
+	p.Error(Finding{Pos: b.Pos(12), Message: "func reference f is after definition"})
+	p.Ok(Finding{Pos: a.Pos(21), Message: "type reference T is before definition"})
+	p.Info(Finding{Pos: a.Pos(3), Message: "skipping"})
+	p.Error(Finding{Pos: a.Pos(3), Message: "var reference v is before definition"})
+	p.Flush()
+
+	want := "--- a.go ---\n" +
+		"1:4: " + color.Colorize(color.Red, "var reference v is before definition") + "\n" +
+		"\n--- b.go ---\n" +
+		"2:3: " + color.Colorize(color.Red, "func reference f is after definition") + "\n"
+	if out.String() != want {
+		t.Errorf("Unexpected output:\n got %q\nwant %q", out.String(), want)
+	}
+}
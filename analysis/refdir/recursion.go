@@ -0,0 +1,83 @@
+package refdir
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// recursionGroups assigns each function and method declared in the package the id of its
+// strongly connected component in the package's reference graph. Two distinct functions
+// with the same id are mutually recursive.
+func recursionGroups(pass *analysis.Pass) map[*types.Func]int {
+	calls := make(map[*types.Func][]*types.Func)
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Body == nil {
+				continue
+			}
+			caller, ok := pass.TypesInfo.Defs[fd.Name].(*types.Func)
+			if !ok {
+				continue
+			}
+			ast.Inspect(fd.Body, func(n ast.Node) bool {
+				if ident, ok := n.(*ast.Ident); ok {
+					if callee, ok := pass.TypesInfo.Uses[ident].(*types.Func); ok && callee.Pkg() == pass.Pkg {
+						calls[caller] = append(calls[caller], callee.Origin())
+					}
+				}
+				return true
+			})
+		}
+	}
+
+	return stronglyConnected(calls)
+}
+
+// stronglyConnected assigns each node of graph, given as adjacency lists, the id of its
+// strongly connected component.
+func stronglyConnected[T comparable](graph map[T][]T) map[T]int {
+	// Tarjan's algorithm.
+	var (
+		groups  = make(map[T]int)
+		index   = make(map[T]int)
+		lowlink = make(map[T]int)
+		onStack = make(map[T]bool)
+		stack   []T
+		visit   func(node T)
+	)
+	visit = func(node T) {
+		index[node] = len(index)
+		lowlink[node] = index[node]
+		stack = append(stack, node)
+		onStack[node] = true
+		for _, next := range graph[node] {
+			if _, seen := index[next]; !seen {
+				visit(next)
+				lowlink[node] = min(lowlink[node], lowlink[next])
+			} else if onStack[next] {
+				lowlink[node] = min(lowlink[node], index[next])
+			}
+		}
+		if lowlink[node] != index[node] {
+			return
+		}
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			groups[top] = index[node]
+			if top == node {
+				break
+			}
+		}
+	}
+	for node := range graph {
+		if _, seen := index[node]; !seen {
+			visit(node)
+		}
+	}
+	return groups
+}
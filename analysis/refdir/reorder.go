@@ -0,0 +1,241 @@
+package refdir
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"slices"
+	"strings"
+)
+
+// Reorder returns the source of file with its top-level declarations reordered so that
+// references between them follow order. Declarations keep their doc comments and the
+// comments and blank lines above them, and otherwise stay in their original relative
+// order. Only references within the file are considered. Reorder fails, naming the
+// conflicting declarations, when no ordering satisfies all references, as with mutually
+// recursive functions under the default directions.
+func Reorder(fset *token.FileSet, file *ast.File, info *types.Info, src []byte, order map[RefKind]Direction) ([]byte, error) {
+	tf := fset.File(file.Pos())
+
+	// Imports stay in the preamble; every other declaration is a movable unit.
+	var decls []ast.Decl
+	for _, decl := range file.Decls {
+		if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+			continue
+		}
+		decls = append(decls, decl)
+	}
+	if len(decls) < 2 {
+		return src, nil
+	}
+
+	// Each unit's text runs from the end of the previous declaration to its own end, so it
+	// carries the blank lines and free-floating comments above it.
+	texts := make([]string, len(decls))
+	prevEnd := tf.LineStart(1)
+	for i, decl := range decls {
+		start, end := declLines(tf, decl)
+		if i > 0 && start < prevEnd {
+			return nil, fmt.Errorf("declarations %s and %s share a line", declName(decls[i-1]), declName(decl))
+		}
+		if i == 0 {
+			prevEnd = start
+		}
+		texts[i] = string(src[tf.Offset(prevEnd):tf.Offset(end)])
+		prevEnd = end
+	}
+	firstStart, _ := declLines(tf, decls[0])
+	preamble := string(src[:tf.Offset(firstStart)])
+	tail := string(src[tf.Offset(prevEnd):])
+
+	after := declConstraints(tf, decls, info, order)
+	newOrder, err := orderUnits(decls, after)
+	if err != nil {
+		return nil, err
+	}
+	if slices.IsSorted(newOrder) {
+		return src, nil
+	}
+
+	var b strings.Builder
+	b.WriteString(preamble)
+	for i, unit := range newOrder {
+		text := texts[unit]
+		if i > 0 && newOrder[i-1] != unit-1 && !strings.HasPrefix(text, "\n") {
+			// Keep declarations that used to be adjacent to others apart from their new neighbors.
+			text = "\n" + text
+		}
+		b.WriteString(text)
+	}
+	b.WriteString(tail)
+	return format.Source([]byte(b.String()))
+}
+
+// declConstraints returns, for each declaration, the declarations that must come before it.
+func declConstraints(tf *token.File, decls []ast.Decl, info *types.Info, order map[RefKind]Direction) [][]int {
+	unitAt := func(pos token.Pos) int {
+		if !pos.IsValid() || tf.Base() > int(pos) || int(pos) > tf.Base()+tf.Size() {
+			return -1
+		}
+		for i, decl := range decls {
+			if decl.Pos() <= pos && pos < decl.End() {
+				return i
+			}
+		}
+		return -1
+	}
+
+	after := make([][]int, len(decls))
+	for ref, decl := range decls {
+		var recv *ast.FieldList
+		if fd, ok := decl.(*ast.FuncDecl); ok {
+			recv = fd.Recv
+		}
+		ast.Inspect(decl, func(n ast.Node) bool {
+			ident, ok := n.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			kind, ok := declRefKind(ident, info.Uses[ident], recv)
+			if !ok {
+				return true
+			}
+			def := unitAt(info.Uses[ident].Pos())
+			if def < 0 || def == ref {
+				return true
+			}
+			switch order[kind] {
+			case Down:
+				after[def] = append(after[def], ref)
+			case Up:
+				after[ref] = append(after[ref], def)
+			case Ignore, Either:
+			}
+			return true
+		})
+	}
+	return after
+}
+
+// orderUnits sorts the declarations topologically, taking the earliest available one at
+// each step so that declarations only move when they have to.
+func orderUnits(decls []ast.Decl, after [][]int) ([]int, error) {
+	pending := make([]int, len(decls))
+	for unit, before := range after {
+		pending[unit] = len(before)
+	}
+	next := make([][]int, len(decls))
+	for unit, before := range after {
+		for _, b := range before {
+			next[b] = append(next[b], unit)
+		}
+	}
+
+	placed := make([]bool, len(decls))
+	newOrder := make([]int, 0, len(decls))
+	for len(newOrder) < len(decls) {
+		unit := -1
+		for u := range decls {
+			if !placed[u] && pending[u] == 0 {
+				unit = u
+				break
+			}
+		}
+		if unit < 0 {
+			return nil, orderConflict(decls, after, placed)
+		}
+		placed[unit] = true
+		newOrder = append(newOrder, unit)
+		for _, n := range next[unit] {
+			pending[n]--
+		}
+	}
+	return newOrder, nil
+}
+
+// orderConflict describes the cycles among the declarations that could not be placed.
+func orderConflict(decls []ast.Decl, after [][]int, placed []bool) error {
+	graph := make(map[int][]int)
+	for unit, before := range after {
+		if placed[unit] {
+			continue
+		}
+		for _, b := range before {
+			if !placed[b] {
+				graph[unit] = append(graph[unit], b)
+			}
+		}
+	}
+	members := make(map[int][]int)
+	for unit, group := range stronglyConnected(graph) {
+		members[group] = append(members[group], unit)
+	}
+
+	var cycles []string
+	for _, units := range members {
+		if len(units) < 2 {
+			continue
+		}
+		slices.Sort(units)
+		names := make([]string, 0, len(units))
+		for _, unit := range units {
+			names = append(names, declName(decls[unit]))
+		}
+		cycles = append(cycles, strings.Join(names, ", "))
+	}
+	slices.Sort(cycles)
+	if len(cycles) == 0 {
+		return errors.New("no valid ordering of declarations")
+	}
+	return fmt.Errorf("no valid ordering of declarations, conflicting: %s", strings.Join(cycles, "; "))
+}
+
+// declRefKind classifies a reference to obj for reordering. recv is the receiver of the
+// enclosing method, if any.
+func declRefKind(ident *ast.Ident, obj types.Object, recv *ast.FieldList) (RefKind, bool) {
+	switch obj := obj.(type) {
+	case *types.Func:
+		if sig, ok := obj.Type().(*types.Signature); ok && sig.Recv() != nil && types.IsInterface(sig.Recv().Type()) {
+			return IfaceType, true
+		}
+		return Func, true
+	case *types.TypeName:
+		if recv != nil && recv.Pos() <= ident.Pos() && ident.Pos() < recv.End() {
+			return RecvType, true
+		}
+		return Type, true
+	case *types.Var:
+		if obj.IsField() {
+			return Field, true
+		}
+		return Var, obj.Pkg() != nil && obj.Parent() == obj.Pkg().Scope()
+	case *types.Const:
+		return Const, obj.Pkg() != nil && obj.Parent() == obj.Pkg().Scope()
+	}
+	return "", false
+}
+
+// declName names a declaration in messages.
+func declName(decl ast.Decl) string {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Recv != nil && len(d.Recv.List) > 0 {
+			return types.ExprString(d.Recv.List[0].Type) + "." + d.Name.Name
+		}
+		return d.Name.Name
+	case *ast.GenDecl:
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				return s.Name.Name
+			case *ast.ValueSpec:
+				return s.Names[0].Name
+			}
+		}
+		return d.Tok.String()
+	}
+	return "declaration"
+}
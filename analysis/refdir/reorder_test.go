@@ -0,0 +1,107 @@
+package refdir
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+)
+
+func TestReorder(t *testing.T) {
+	src := `package p
+
+import "fmt"
+
+// helper is called by run.
+func helper() {}
+
+func run() {
+	helper()
+	fmt.Println(Config{})
+}
+
+// Config is used by run.
+type Config struct{}
+`
+	want := `package p
+
+import "fmt"
+
+// Config is used by run.
+type Config struct{}
+
+func run() {
+	helper()
+	fmt.Println(Config{})
+}
+
+// helper is called by run.
+func helper() {}
+`
+	got, err := reorderSource(t, src)
+	if err != nil {
+		t.Fatalf("Failed to reorder: %v", err)
+	}
+	if got != want {
+		t.Errorf("Unexpected result:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestReorderOrdered(t *testing.T) {
+	src := `package p
+
+var a = 1
+var b = a + 1
+
+func run() int { return helper() + b }
+
+func helper() int { return a }
+`
+	got, err := reorderSource(t, src)
+	if err != nil {
+		t.Fatalf("Failed to reorder: %v", err)
+	}
+	if got != src {
+		t.Errorf("Expected an ordered file to be unchanged, got %q", got)
+	}
+}
+
+func TestReorderConflict(t *testing.T) {
+	src := `package p
+
+func ping(n int) {
+	if n > 0 {
+		pong(n - 1)
+	}
+}
+
+func pong(n int) {
+	ping(n)
+}
+`
+	_, err := reorderSource(t, src)
+	if err == nil || !strings.Contains(err.Error(), "conflicting: ping, pong") {
+		t.Errorf("Expected a conflict between ping and pong, got %v", err)
+	}
+}
+
+// reorderSource type-checks src as a single-file package and reorders it with the
+// default directions.
+func reorderSource(t *testing.T, src string) (string, error) {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	info := &types.Info{Uses: make(map[*ast.Ident]types.Object)}
+	conf := types.Config{Importer: importer.Default()}
+	if _, err := conf.Check("p", fset, []*ast.File{file}, info); err != nil {
+		t.Fatalf("Failed to type-check source: %v", err)
+	}
+	out, err := Reorder(fset, file, info, []byte(src), RefOrder)
+	return string(out), err
+}
@@ -0,0 +1,20 @@
+package adjacency
+
+func run() {
+	near()
+	far() // want "func reference far is before definition, 4 declarations away"
+	shared()
+}
+
+func near() {}
+
+func other() {
+	shared()
+}
+
+func spacer() {}
+
+func far() {}
+
+// Functions with several callers may be anywhere below them.
+func shared() {}
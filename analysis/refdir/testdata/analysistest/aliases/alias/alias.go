@@ -0,0 +1,16 @@
+package alias
+
+import "bytes"
+
+type earlier struct{}
+
+type Earlier = earlier
+
+type Buffer = bytes.Buffer
+
+// References are ordered against the alias declarations.
+func useAliases(Earlier, Buffer) {}
+
+func useAliasBeforeDecl(Later) {} // want "type reference Later is before definition"
+
+type Later = earlier
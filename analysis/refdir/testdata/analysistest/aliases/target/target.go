@@ -0,0 +1,27 @@
+package target
+
+import "bytes"
+
+type earlier struct{}
+
+type Earlier = earlier
+
+type Buffer = bytes.Buffer
+
+type Celsius = float64
+
+// References are ordered against the types the aliases stand for.
+func useAlias(Earlier) {}
+
+type Later = later // want "type reference later is before definition"
+
+func useAliasBeforeTarget(Later) {} // want "type reference Later is before definition"
+
+type later struct{}
+
+func useAliasBeforeDecl(Alias) {}
+
+type Alias = earlier
+
+// Aliases of types from other packages have nothing to order against.
+func useOtherAliases(Buffer, Celsius) {}
@@ -0,0 +1,10 @@
+//go:build refdir_excluded
+
+package buildtags
+
+// Not part of the build, so the ordering error below is never reported.
+func PlatformFunc() {
+	_ = PlatformType{}
+}
+
+type PlatformType struct{}
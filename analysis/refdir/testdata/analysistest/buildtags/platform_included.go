@@ -0,0 +1,9 @@
+//go:build !refdir_excluded
+
+package buildtags
+
+func PlatformFunc() {
+	_ = PlatformType{} // want "type reference PlatformType is before definition"
+}
+
+type PlatformType struct{}
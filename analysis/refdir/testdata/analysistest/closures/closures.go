@@ -0,0 +1,11 @@
+package closures
+
+func run() {
+	// The deferred closure only runs after the rest of run.
+	defer func() {
+		helper()
+	}()
+	helper() // want "func reference helper is before definition"
+}
+
+func helper() {}
@@ -0,0 +1,8 @@
+package fileonly
+
+func early() {}
+
+// The config file sets func-dir to up, so this is in order.
+func late() {
+	early()
+}
@@ -0,0 +1,8 @@
+package flagoverride
+
+func early() {}
+
+// The func-dir flag overrides the config file.
+func late() {
+	early() // want "func reference early is after definition"
+}
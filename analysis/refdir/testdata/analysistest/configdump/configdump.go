@@ -0,0 +1,9 @@
+package configdump
+
+// With -config-dump, the package is not checked, so the reference to helper above its
+// definition is not reported.
+func caller() {
+	helper()
+}
+
+func helper() {}
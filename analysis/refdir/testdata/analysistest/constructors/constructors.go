@@ -0,0 +1,56 @@
+package constructors
+
+import "errors"
+
+type Counter struct {
+	n int
+}
+
+func NewCounter() *Counter { return &Counter{} }
+
+func NewCounterAt(n int) (*Counter, error) {
+	if n < 0 {
+		return nil, errors.New("negative")
+	}
+	return &Counter{n: n}, nil
+}
+
+func (c *Counter) Inc() { c.n++ }
+
+type Timer struct {
+	ticks int
+}
+
+func (t *Timer) Tick() { t.ticks++ }
+
+func NewTimer() Timer { return Timer{} } // want `constructor NewTimer is not declared right after its type Timer`
+
+func NewPair[T any](a, b T) Pair[T] { return Pair[T]{a: a, b: b} } // want `constructor NewPair is not declared right after its type Pair` "type reference Pair is before definition" "type reference Pair is before definition"
+
+type Pair[T any] struct {
+	a, b T
+}
+
+// Newline does not construct a Timer, and New does.
+func Newline() Timer { return Timer{} }
+
+type Clock struct{}
+
+func New() *Clock { return &Clock{} }
+
+func NewError() error { return errors.New("not a type of the package") }
+
+func NewExternal() *External { return &External{} }
+
+type Meter struct{}
+
+func (m *Meter) Mark() {}
+
+func NewMeter() *Meter { return &Meter{} } //refdir:ignore
+
+type Gauge struct{}
+
+func (g *Gauge) Set() {}
+
+//nolint:refdir // Kept after the methods on purpose.
+func NewGauge() *Gauge { return &Gauge{} }
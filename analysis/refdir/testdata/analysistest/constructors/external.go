@@ -0,0 +1,4 @@
+package constructors
+
+// Constructors in another file than their type are not checked.
+type External struct{}
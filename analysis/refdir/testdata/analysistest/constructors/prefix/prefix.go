@@ -0,0 +1,7 @@
+package prefix
+
+type Counter struct{}
+
+func NewCounter() *Counter { return &Counter{} }
+
+func MakeCounter() *Counter { return &Counter{} } // want `constructor MakeCounter is not declared right after its type Counter`
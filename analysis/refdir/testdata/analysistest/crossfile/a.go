@@ -0,0 +1,8 @@
+package crossfile
+
+type TypeInA struct{}
+
+func FuncInA() {
+	_ = TypeInB{} // want "type reference TypeInB is before definition in b.go"
+	FuncInB()
+}
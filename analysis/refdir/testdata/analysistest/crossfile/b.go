@@ -0,0 +1,8 @@
+package crossfile
+
+type TypeInB struct{}
+
+func FuncInB() {
+	_ = TypeInA{}
+	FuncInA() // want "func reference FuncInA is after definition in a.go"
+}
@@ -0,0 +1,21 @@
+package defaultdirs
+
+var _ = blankLaterFunc
+
+var _ BlankLaterType // want "type reference BlankLaterType is before definition"
+
+var _ = BlankLaterType{} // want "type reference BlankLaterType is before definition"
+
+func TestBlankAssign() {
+	_ = BlankLaterType{}                    // want "type reference BlankLaterType is before definition"
+	_, _ = blankLaterFunc, BlankLaterType{} // want "type reference BlankLaterType is before definition"
+}
+
+func blankLaterFunc() {}
+
+type BlankLaterType struct{}
+
+func TestBlankAssignUp() {
+	_ = BlankLaterType{}
+	_ = blankLaterFunc // want "func reference blankLaterFunc is after definition"
+}
@@ -0,0 +1,23 @@
+package defaultdirs
+
+// Keyed field names are skipped; the types and values in the literals are checked.
+func useNestedLiterals() []*litOuter { // want "type reference litOuter is before definition"
+	return []*litOuter{ // want "type reference litOuter is before definition"
+		{
+			Inner: litInner{Value: litLaterValue}, // want "type reference litInner is before definition" "var reference litLaterValue is before definition"
+			Items: []litItem{{Name: "a"}, {"b"}},  // want "type reference litItem is before definition"
+		},
+		&litOuter{Inner: litInner{}}, // want "type reference litOuter is before definition" "type reference litInner is before definition"
+	}
+}
+
+type litInner struct{ Value int }
+
+type litItem struct{ Name string }
+
+type litOuter struct {
+	Inner litInner
+	Items []litItem
+}
+
+var litLaterValue = 1
@@ -0,0 +1,21 @@
+package defaultdirs
+
+var ArrayOfLenBelow [ArrayLenBelow]int // want "const reference ArrayLenBelow is before definition"
+
+type ArrayTypeOfLenBelow [ArrayLenBelow * 2]string // want "const reference ArrayLenBelow is before definition"
+
+func TestArrayLenInBody() {
+	var local [ArrayLenBelow]int // want "const reference ArrayLenBelow is before definition"
+	_ = local
+	_ = [...]int{ArrayLenBelow: 1} // want "const reference ArrayLenBelow is before definition"
+}
+
+const ArrayLenBelow = 4
+
+const ArrayLenAbove = 2
+
+func TestArrayLenAbove() [ArrayLenAbove]int {
+	var local [ArrayLenAbove]bool
+	_ = local
+	return [ArrayLenAbove]int{}
+}
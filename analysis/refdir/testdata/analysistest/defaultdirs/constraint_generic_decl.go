@@ -0,0 +1,19 @@
+package defaultdirs
+
+// Constraints are type references wherever the type parameter list appears, and methods
+// selected through a type parameter are skipped.
+func TestConstraintMethods[T TestConstraintBelow](v T) { // want "type reference TestConstraintBelow is before definition"
+	v.Below()
+}
+
+type TestConstraintBox[T TestConstraintBelow] struct { // want "type reference TestConstraintBelow is before definition"
+	v T
+}
+
+func (b *TestConstraintBox[T]) Get() T {
+	return b.v
+}
+
+type TestConstraintBelow interface {
+	Below()
+}
@@ -0,0 +1,19 @@
+package defaultdirs
+
+type closerDeferGoDown struct{}
+
+// defer and go statements wrap ordinary calls: their callees are func references.
+func startDeferGoDown() {
+	defer cleanupDeferGoDown()
+	go workerDeferGoDown()
+
+	c := &closerDeferGoDown{}
+	defer c.Close()
+	go c.Close()
+}
+
+func cleanupDeferGoDown() {}
+
+func workerDeferGoDown() {}
+
+func (*closerDeferGoDown) Close() {}
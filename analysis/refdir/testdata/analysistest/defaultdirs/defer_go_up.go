@@ -0,0 +1,21 @@
+package defaultdirs
+
+type closerDeferGoUp struct{}
+
+func (*closerDeferGoUp) Close() {}
+
+func cleanupDeferGoUp() {}
+
+func workerDeferGoUp() {}
+
+func startDeferGoUp() {
+	defer cleanupDeferGoUp() // want "func reference cleanupDeferGoUp is after definition"
+	go workerDeferGoUp()     // want "func reference workerDeferGoUp is after definition"
+
+	c := &closerDeferGoUp{}
+	defer c.Close() // want "func reference Close is after definition"
+	go c.Close()    // want "func reference Close is after definition"
+	defer func() {
+		cleanupDeferGoUp() // want "func reference cleanupDeferGoUp is after definition"
+	}()
+}
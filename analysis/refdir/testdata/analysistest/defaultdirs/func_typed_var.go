@@ -0,0 +1,26 @@
+package defaultdirs
+
+// A package-scope var of function type is a var, whatever its value: calling it is a var
+// reference, ordered upwards, not a func reference.
+func callFuncTypedVars() {
+	funcTypedHandler()            // want "var reference funcTypedHandler is before definition"
+	funcTypedLiteral()            // want "var reference funcTypedLiteral is before definition"
+	funcTypedCallbacks{}.onDone() // want "type reference funcTypedCallbacks is before definition"
+}
+
+var funcTypedHandler func()
+
+var funcTypedLiteral = func() {}
+
+// Calling a func-typed field is a field reference, ignored by default.
+type funcTypedCallbacks struct {
+	onDone func()
+}
+
+func callFuncTypedVarsLater() {
+	funcTypedHandler()
+	funcTypedLiteral()
+	funcTypedHandler = funcTypedTarget
+}
+
+func funcTypedTarget() {}
@@ -0,0 +1,21 @@
+package defaultdirs
+
+// References between the specs of a single declaration are ordered by spec, each reported
+// at its own position rather than at the var, const or type keyword.
+var (
+	multiSpecA = multiSpecB + multiSpecC // want "var reference multiSpecB is before definition" "var reference multiSpecC is before definition"
+	multiSpecB = 1
+	multiSpecC = multiSpecB + multiSpecD // want "var reference multiSpecD is before definition"
+	multiSpecD = 2
+)
+
+// Const blocks are a unit unless -strict-iota is set.
+const (
+	multiSpecConstA = multiSpecConstB
+	multiSpecConstB = 1
+)
+
+type (
+	MultiSpecList []MultiSpecItem // want "type reference MultiSpecItem is before definition"
+	MultiSpecItem struct{ next *MultiSpecList }
+)
@@ -0,0 +1,38 @@
+package defaultdirs
+
+import "io"
+
+type Declarer interface {
+	Declared()
+}
+
+type Embedder interface {
+	Declarer
+}
+
+// Ordered against Declarer, which declares the method.
+func useEmbedder(e Embedder) {
+	e.Declared()
+}
+
+type Outer interface {
+	Inner // want "type reference Inner is before definition"
+}
+
+func useOuter(o Outer) {
+	o.Promoted() // want "ifacetype reference Promoted is before definition"
+}
+
+type Inner interface {
+	Promoted()
+}
+
+// The chain leaves the package at io.Reader, so Read is ordered against ReadCloser.
+type ReadCloser interface {
+	io.Reader
+	Close() error
+}
+
+func useReadCloser(rc ReadCloser) {
+	_, _ = rc.Read(nil)
+}
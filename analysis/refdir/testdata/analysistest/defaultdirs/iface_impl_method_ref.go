@@ -0,0 +1,18 @@
+package defaultdirs
+
+type implLoud struct{}
+
+func (implLoud) Shout() {}
+
+// The same method is a func reference through the concrete type, and a reference to the
+// interface through the interface.
+func callImplBoth(c implLoud, s ImplShouter) { // want "type reference ImplShouter is before definition"
+	c.Shout()             // want "func reference Shout is after definition"
+	s.Shout()             // want "ifacetype reference Shout is before definition"
+	var i ImplShouter = c // want "type reference ImplShouter is before definition"
+	i.Shout()             // want "ifacetype reference Shout is before definition"
+}
+
+type ImplShouter interface {
+	Shout()
+}
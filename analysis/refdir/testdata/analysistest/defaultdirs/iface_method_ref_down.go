@@ -0,0 +1,9 @@
+package defaultdirs
+
+func TestIfaceMethodRefDown(v TestIfaceMethodRefDownIface) { // want "type reference TestIfaceMethodRefDownIface is before definition"
+	v.DummyMethod() // want "ifacetype reference DummyMethod is before definition"
+}
+
+type TestIfaceMethodRefDownIface interface {
+	DummyMethod()
+}
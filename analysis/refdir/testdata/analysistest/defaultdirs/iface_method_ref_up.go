@@ -0,0 +1,9 @@
+package defaultdirs
+
+type TestIfaceMethodRefUpIface interface {
+	DummyMethod()
+}
+
+func TestIfaceMethodRefUp(v TestIfaceMethodRefUpIface) {
+	v.DummyMethod()
+}
@@ -0,0 +1,30 @@
+package defaultdirs
+
+// Selections through a pointer alias, and through a pointer to a struct embedding the
+// interface, still resolve to the interface.
+type pointedHolderPtr = *pointedHolder // want "type reference pointedHolder is before definition"
+
+func usePointerAlias(h pointedHolderPtr) {
+	h.Pointed() // want "ifacetype reference Pointed is before definition"
+}
+
+func usePointer(h *pointedHolder) { // want "type reference pointedHolder is before definition"
+	h.Pointed() // want "ifacetype reference Pointed is before definition"
+}
+
+type pointedHolder struct {
+	PointedIface // want "type reference PointedIface is before definition"
+}
+
+type PointedIface interface {
+	Pointed()
+}
+
+type pointedValue struct{}
+
+// Non-pointer receivers are unaffected: Value is a func reference.
+func useValue(v pointedValue) {
+	v.Value()
+}
+
+func (pointedValue) Value() {}
@@ -0,0 +1,28 @@
+package defaultdirs
+
+import "io"
+
+// Methods promoted through an embedded interface field are ordered like calls on the
+// interface itself, against the interface that declares them.
+type EmbeddingStruct struct {
+	io.Reader
+	StructEmbedded // want "type reference StructEmbedded is before definition"
+}
+
+type EmbeddingWrapper struct {
+	*EmbeddingStruct
+}
+
+func useEmbeddingStruct(s EmbeddingStruct, w EmbeddingWrapper) {
+	_, _ = s.Read(nil)
+	s.Embedded()                // want "ifacetype reference Embedded is before definition"
+	s.StructEmbedded.Embedded() // want "ifacetype reference Embedded is before definition"
+	w.Embedded()                // want "ifacetype reference Embedded is before definition"
+	w.Concrete()
+}
+
+type StructEmbedded interface {
+	Embedded()
+}
+
+func (s *EmbeddingStruct) Concrete() {}
@@ -0,0 +1,22 @@
+package defaultdirs
+
+func TestIgnoreDirectiveOnRef() {
+	_ = IgnoredVarAtEnd //refdir:ignore
+}
+
+func TestIgnoreDirectiveWithKinds() {
+	_ = IgnoredConstAtEnd    //refdir:ignore var,const
+	_ = NotIgnoredConstAtEnd //refdir:ignore var // want "const reference NotIgnoredConstAtEnd is before definition"
+}
+
+func TestIgnoreDirectiveOnDecl() {
+	_ = IgnoredAtDeclVar
+}
+
+var IgnoredVarAtEnd string
+
+const IgnoredConstAtEnd = 1
+
+const NotIgnoredConstAtEnd = 2
+
+var IgnoredAtDeclVar string //refdir:ignore
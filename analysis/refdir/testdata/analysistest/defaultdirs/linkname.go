@@ -0,0 +1,12 @@
+package defaultdirs
+
+import _ "unsafe" // For go:linkname.
+
+// Bodyless declarations linked to other symbols still have a Go source position.
+//
+//go:linkname linkedNanotime runtime.nanotime
+func linkedNanotime() int64
+
+func useLinked() int64 {
+	return linkedNanotime() // want "func reference linkedNanotime is after definition"
+}
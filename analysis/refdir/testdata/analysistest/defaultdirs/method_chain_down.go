@@ -0,0 +1,21 @@
+package defaultdirs
+
+func TestDownMethodChain() {
+	FindChainOvenDown().
+		WarmUp().
+		WarmUp().
+		Bake()
+}
+
+type ChainOvenDown struct {
+	temperature float32
+}
+
+func FindChainOvenDown() *ChainOvenDown { return &ChainOvenDown{} }
+
+func (s *ChainOvenDown) WarmUp() *ChainOvenDown {
+	s.temperature += 42
+	return s
+}
+
+func (s *ChainOvenDown) Bake() {}
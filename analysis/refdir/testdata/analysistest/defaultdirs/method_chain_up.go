@@ -0,0 +1,21 @@
+package defaultdirs
+
+type ChainOvenUp struct {
+	temperature float32
+}
+
+func FindChainOvenUp() *ChainOvenUp { return &ChainOvenUp{} }
+
+func (s *ChainOvenUp) WarmUp() *ChainOvenUp {
+	s.temperature += 42
+	return s
+}
+
+func (s *ChainOvenUp) Bake() {}
+
+func TestUpMethodChain() {
+	FindChainOvenUp(). // want "func reference FindChainOvenUp is after definition"
+				WarmUp(). // want "func reference WarmUp is after definition"
+				WarmUp(). // want "func reference WarmUp is after definition"
+				Bake()    // want "func reference Bake is after definition"
+}
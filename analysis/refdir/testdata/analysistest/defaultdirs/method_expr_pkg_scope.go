@@ -0,0 +1,22 @@
+package defaultdirs
+
+type TestMethodExprPkgScopeType struct{}
+
+func (TestMethodExprPkgScopeType) Method() {}
+
+func (*TestMethodExprPkgScopeType) PtrMethod() {}
+
+type TestMethodExprPkgScopeIface interface {
+	IfaceMethod()
+}
+
+var (
+	_ = TestMethodExprPkgScopeType.Method       // want "func reference Method is after definition"
+	_ = (*TestMethodExprPkgScopeType).PtrMethod // want "func reference PtrMethod is after definition"
+	_ = TestMethodExprPkgScopeIface.IfaceMethod // want "func reference IfaceMethod is after definition"
+	_ = TestMethodExprPkgScopeLater.Method      // want "type reference TestMethodExprPkgScopeLater is before definition"
+)
+
+type TestMethodExprPkgScopeLater struct{}
+
+func (TestMethodExprPkgScopeLater) Method() {}
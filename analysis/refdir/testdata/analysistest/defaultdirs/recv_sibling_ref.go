@@ -0,0 +1,20 @@
+package defaultdirs
+
+type TestRecvSiblingA struct{}
+
+func (a *TestRecvSiblingA) First() {
+	_ = (*TestRecvSiblingA)(a)
+	_ = TestRecvSiblingB{} // want "type reference TestRecvSiblingB is before definition"
+}
+
+func (b *TestRecvSiblingB) Second() { // want "recvtype reference TestRecvSiblingB is before definition"
+	_ = TestRecvSiblingB{}
+	_ = TestRecvSiblingA{}
+}
+
+type TestRecvSiblingB struct{}
+
+func (b TestRecvSiblingB) Third() {
+	var _ any = &TestRecvSiblingA{}
+	_ = any(b).(TestRecvSiblingB)
+}
@@ -0,0 +1,20 @@
+package defaultdirs
+
+func TestTypeSwitchRef(x any) int {
+	switch v := x.(type) {
+	case *TypeSwitchLater: // want "type reference TypeSwitchLater is before definition"
+		return v.n
+	case TypeSwitchEarlier:
+		return v.n
+	}
+	return 0
+}
+
+func TestTypeAssertionRef(x any) bool {
+	_, ok := x.(*TypeSwitchLater) // want "type reference TypeSwitchLater is before definition"
+	return ok
+}
+
+type TypeSwitchLater struct {
+	n int
+}
@@ -0,0 +1,18 @@
+package defaultdirs
+
+type TypeSwitchEarlier struct {
+	n int
+}
+
+func TestTypeSwitchRefUp(x any) int {
+	switch v := x.(type) {
+	case TypeSwitchEarlier:
+		return v.n
+	case *TypeSwitchEarlier:
+		return v.n + 1
+	}
+	if v, ok := x.(TypeSwitchEarlier); ok {
+		return v.n
+	}
+	return 0
+}
@@ -0,0 +1,6 @@
+package density // want `100% of references \(2 of 2\) are to definitions in other files of the package, above 50%`
+
+func useB() {
+	helperB()
+	_ = TypeB{}
+}
@@ -0,0 +1,5 @@
+package density
+
+type TypeB struct{}
+
+func helperB() {}
@@ -0,0 +1,10 @@
+package dotimports
+
+import . "strings"
+
+// Dot-imported names are defined in another package, so they are never out of order.
+func trim(s string) string {
+	var b Builder
+	b.WriteString(TrimSpace(s))
+	return b.String()
+}
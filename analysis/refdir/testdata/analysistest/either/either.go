@@ -0,0 +1,15 @@
+package either
+
+type Before struct{}
+
+func UsesBoth() {
+	_ = Before{}
+	_ = After{}
+	helper()
+}
+
+type After struct{}
+
+func helper() {
+	UsesBoth() // want "func reference UsesBoth is after definition"
+}
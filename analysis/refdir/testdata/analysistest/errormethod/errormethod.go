@@ -0,0 +1,5 @@
+package errormethod
+
+func describe(err error) string { // want "skipping predeclared type error" "skipping predeclared type string"
+	return err.Error() // want "skipping var ident err" "skipping error.Error\\(\\) builtin method"
+}
@@ -0,0 +1,13 @@
+package excludenames
+
+func UsesLaterDecls() {
+	_ = mustInit
+	_ = fixtureData
+	_ = laterVar // want "var reference laterVar is before definition"
+}
+
+var mustInit = true
+
+var fixtureData = []int{1}
+
+var laterVar = 1
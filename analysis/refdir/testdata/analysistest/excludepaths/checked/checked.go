@@ -0,0 +1,7 @@
+package checked
+
+func Checked() {
+	_ = checkedVar // want "var reference checkedVar is before definition"
+}
+
+var checkedVar int
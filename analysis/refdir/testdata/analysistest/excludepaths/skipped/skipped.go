@@ -0,0 +1,7 @@
+package skipped // want `skipping package excluded by -exclude-paths pattern "excludepaths/sk\*"`
+
+func Skipped() {
+	_ = skippedVar
+}
+
+var skippedVar int
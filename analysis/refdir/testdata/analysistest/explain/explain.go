@@ -0,0 +1,7 @@
+package explain // want "^policy: func-dir=up, test-func-dir=down \\(references to functions and methods\\)$" "policy: type-dir=up" "policy: recvtype-dir=up" "policy: ifacetype-dir=up" "policy: field-dir=ignore" "policy: var-dir=up" "policy: const-dir=up" "policy: label-dir=ignore" "policy: pkg-dir=ignore"
+
+func first() {}
+
+func second() {
+	first()
+}
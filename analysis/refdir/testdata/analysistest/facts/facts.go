@@ -0,0 +1,13 @@
+package facts // want package:"3 errors, 0 warnings \\(func:2 var:1\\); worst: run \\(2\\)" `^3 errors, 0 warnings \(func:2 var:1\) in 1 package: .*/facts: 3 errors, 0 warnings \(func:2 var:1\); worst: run \(2\)$`
+
+func helper() {}
+
+func other() {}
+
+func run() {
+	helper()
+	other()
+	_ = later
+}
+
+var later int
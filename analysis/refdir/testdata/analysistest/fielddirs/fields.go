@@ -0,0 +1,23 @@
+package fielddirs
+
+type Before struct {
+	Name string
+}
+
+func UseBefore(b Before) string {
+	return b.Name
+}
+
+func UseLater(l Later) string { // want "type reference Later is before definition"
+	_ = Later{Count: 1} // want "type reference Later is before definition" "field reference Count is before definition"
+	return l.Name       // want "field reference Name is before definition"
+}
+
+type Later struct {
+	Embedded // want "type reference Embedded is before definition"
+	Count    int
+}
+
+type Embedded struct {
+	Name string
+}
@@ -0,0 +1,13 @@
+//refdir:func=up,type=down,label=sideways
+
+package fileoverride
+
+func helper() {}
+
+// Legacy file in bottom-up style.
+func caller() {
+	helper()
+	_ = LaterType{}
+}
+
+type LaterType struct{}
@@ -0,0 +1,11 @@
+package fileoverride
+
+func topCaller() {
+	topHelper()
+}
+
+func topHelper() {}
+
+func lateCaller() {
+	topCaller() // want "func reference topCaller is after definition"
+}
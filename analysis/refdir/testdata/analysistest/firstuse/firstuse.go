@@ -0,0 +1,19 @@
+package firstuse
+
+func early() {}
+
+func Run() {
+	early() // want "func reference early is after definition"
+	early()
+	_ = Later{} // want "type reference Later is before definition"
+}
+
+func Again() {
+	early()
+	_ = Later{}
+	_ = limit // want "var reference limit is before definition"
+}
+
+type Later struct{}
+
+var limit = 1
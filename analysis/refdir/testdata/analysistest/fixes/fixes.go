@@ -0,0 +1,19 @@
+package fixes
+
+// helperAbove is called from below.
+func helperAbove() {}
+
+func UsesHelperAbove() {
+	helperAbove() // want "func reference helperAbove is after definition"
+}
+
+func UsesTypeBelow() {
+	_ = TypeBelow{} // want "type reference TypeBelow is before definition"
+}
+
+// TypeBelow is used from above.
+type TypeBelow struct {
+	Field int
+}
+
+func Last() {}
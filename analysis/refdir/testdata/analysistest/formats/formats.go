@@ -0,0 +1,10 @@
+package formats
+
+func UseLaterType() {
+	_ = LaterType{}
+	laterFunc()
+}
+
+type LaterType struct{}
+
+func laterFunc() {}
@@ -0,0 +1,16 @@
+package aftervars
+
+import "strings"
+
+const prefix = "p"
+
+var upper = strings.ToUpper(prefix)
+
+func init() {}
+
+type T struct{}
+
+func init() {} // want "init func is not declared right after the const and var declarations of its file"
+
+// main is an ordinary func outside of main packages.
+func main() {}
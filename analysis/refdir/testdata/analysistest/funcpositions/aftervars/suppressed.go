@@ -0,0 +1,8 @@
+package aftervars
+
+type S struct{}
+
+func init() {} //refdir:ignore
+
+//nolint:refdir // Registered after the types on purpose.
+func init() {}
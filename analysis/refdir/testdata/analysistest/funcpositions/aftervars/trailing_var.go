@@ -0,0 +1,5 @@
+package aftervars
+
+func init() {} // want "init func is not declared right after the const and var declarations of its file"
+
+var trailing = 1
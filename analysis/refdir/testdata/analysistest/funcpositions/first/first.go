@@ -0,0 +1,13 @@
+package main
+
+import "fmt"
+
+func init() {}
+
+var value = 1
+
+func main() { // want "main func is not the first declaration of its file"
+	fmt.Println("main")
+}
+
+func init() {} // want "init func is not the first declaration of its file"
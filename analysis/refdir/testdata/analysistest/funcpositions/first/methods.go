@@ -0,0 +1,8 @@
+package main
+
+func init() {}
+
+type config struct{}
+
+// A method named init is not an init func.
+func (config) init() {}
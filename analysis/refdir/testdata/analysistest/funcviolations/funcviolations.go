@@ -0,0 +1,26 @@
+package funcviolations
+
+type T struct{}
+
+func (t *T) Set() {
+	first() // want "func reference first is before definition"
+}
+
+func busy() {
+	first()  // want "func reference first is before definition"
+	second() // want "func reference second is before definition"
+	first()  // want "func reference first is before definition"
+}
+
+func calm() {
+	first() // want "func reference first is before definition"
+}
+
+func first() {}
+
+func second() {}
+
+func last() {
+	first()
+	second()
+}
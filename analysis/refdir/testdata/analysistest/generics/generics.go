@@ -0,0 +1,18 @@
+package generics
+
+// Each instantiation of identity refers to the same declaration, so every reference is
+// reported once.
+func useInstantiations() (int, string) {
+	return identity[int](1), identity("a")
+}
+
+func pair[T any](a, b T) []T {
+	return []T{identity(a), identity(b)}
+}
+
+func early() {}
+
+func identity[T any](v T) T {
+	early() // want "func reference early is after definition"
+	return v
+}
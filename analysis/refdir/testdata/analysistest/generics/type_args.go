@@ -0,0 +1,26 @@
+package generics
+
+// Type arguments are type references to their declaration, whatever the instantiation:
+// composite literals, make and explicit instantiations of generic funcs.
+
+type Container[T any] []T
+
+type Earlier struct{}
+
+var literal = Container[MyType]{} // want "type reference MyType is before definition"
+
+var earlierLiteral = Container[Earlier]{}
+
+func useTypeArgs() {
+	_ = make(Container[MyType], 0)   // want "type reference MyType is before definition"
+	_ = Generic[MyType]()            // want "type reference MyType is before definition"
+	_ = Generic[Container[MyType]]() // want "type reference MyType is before definition"
+	_ = Generic[Earlier]()
+}
+
+func Generic[T any]() T {
+	var zero T
+	return zero
+}
+
+type MyType struct{}
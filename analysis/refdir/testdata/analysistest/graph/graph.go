@@ -0,0 +1,14 @@
+package graph
+
+type Item struct{}
+
+func Run() {
+	use(Item{})
+	_ = limit // want "var reference limit is before definition"
+}
+
+func use(Item) {
+	Run() // want "func reference Run is after definition"
+}
+
+var limit = 1
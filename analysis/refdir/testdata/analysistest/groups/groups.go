@@ -0,0 +1,25 @@
+package groups
+
+type Level int
+
+const (
+	Debug Level = Info - 1
+	Info  Level = iota
+	Warn
+)
+
+// Adjacent declarations without blank lines form a group.
+type Pair struct{ first, second Item }
+type Item struct{ level Level }
+
+func useLater() Later { // want "type reference Later is before definition"
+	return Later{} // want "type reference Later is before definition"
+}
+
+type Later struct{}
+
+// With -group-tolerance, a var block is a unit too.
+var (
+	blockFirst  = blockSecond
+	blockSecond = 1
+)
@@ -0,0 +1,27 @@
+package ignorelist
+
+type T struct{}
+
+func use(t T) {
+	legacyHelper()
+	newHelper() // want "func reference newHelper is before definition"
+	t.Old()
+	t.New() // want "func reference New is before definition"
+	_ = Later{}
+	_ = Other{} // want "type reference Other is before definition"
+	_ = fromOptions()
+}
+
+func legacyHelper() {}
+
+func newHelper() {}
+
+func (T) Old() {}
+
+func (T) New() {}
+
+func fromOptions() int { return 0 }
+
+type Later struct{}
+
+type Other struct{}
@@ -0,0 +1,14 @@
+package lenient
+
+// The block is a unit, so references within it are accepted in either order.
+const (
+	KB = 1 << (10 * (iota + 1))
+	MB
+	Page  = 4 * KB
+	Limit = 2 * Max
+	Max   = 64 * MB
+)
+
+const Outside = 2 * Later // want "const reference Later is before definition"
+
+const Later = 1
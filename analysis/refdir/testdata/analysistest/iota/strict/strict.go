@@ -0,0 +1,9 @@
+package strict
+
+const (
+	KB = 1 << (10 * (iota + 1))
+	MB
+	Page  = 4 * KB
+	Limit = 2 * Max // want "const reference Max is before definition"
+	Max   = 64 * MB
+)
@@ -0,0 +1,54 @@
+package labels
+
+func forward(n int) int {
+	if n < 0 {
+		goto done
+	}
+	n++
+done:
+	return n
+}
+
+func backward(n int) int {
+retry:
+	n--
+	if n > 0 {
+		goto retry // want "label reference retry is after definition"
+	}
+	return n
+}
+
+// Labeled break and continue always refer to an enclosing statement.
+func loops(rows [][]int) int {
+	sum := 0
+outer:
+	for _, row := range rows {
+		for _, v := range row {
+			if v < 0 {
+				continue outer
+			}
+			if v == 0 {
+				break outer
+			}
+			sum += v
+		}
+	}
+	return sum
+}
+
+// Labels have their own namespace and function scope: the label done in the closure is
+// unrelated to the variable and to the label of the enclosing function.
+func shadowed(n int) int {
+	done := n
+	f := func() {
+		goto done
+	done:
+	}
+	f()
+	if done > 0 {
+		goto done
+	}
+	n--
+done:
+	return n
+}
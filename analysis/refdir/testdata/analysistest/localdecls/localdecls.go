@@ -0,0 +1,29 @@
+package localdecls
+
+// Parameters and results, including those of function literals, are never checked.
+func sum(n int) (total int) {
+	type pair struct{ a, b int }
+	const k = 2
+	p := pair{n, k}                            // want "type reference pair is after definition" "const reference k is after definition"
+	double := func(x int) int { return x * k } // want "const reference k is after definition"
+	total = double(p.a)                        // want "var reference double is after definition" "var reference p is after definition"
+	return total
+}
+
+// Declarations in function literals at package scope are local too.
+var counter = func() int {
+	count := 0
+	count++      // want "var reference count is after definition"
+	return count // want "var reference count is after definition"
+}
+
+// The implicit object of each type switch clause is declared by the switch guard.
+func describe(x any) int {
+	switch v := x.(type) {
+	case int:
+		return v // want "var reference v is after definition"
+	case []int:
+		return len(v) // want "var reference v is after definition"
+	}
+	return 0
+}
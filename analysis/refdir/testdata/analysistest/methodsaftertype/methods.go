@@ -0,0 +1,30 @@
+package methodsaftertype
+
+func (c *Counter) Inc() { // want `method \(\*Counter\).Inc is declared before its receiver type Counter`
+	c.n++
+}
+
+type Counter struct {
+	n int
+}
+
+func (c Counter) Value() int {
+	return c.n
+}
+
+func (p Pair[T]) First() T { // want `method \(Pair\[T\]\).First is declared before its receiver type Pair`
+	return p.a
+}
+
+type Pair[T any] struct {
+	a, b T
+}
+
+func (e External) Name() string { return "external" }
+
+func (s *Suppressed) Reset() {} //refdir:ignore
+
+//nolint:refdir // Kept above its type on purpose.
+func (s *Suppressed) Clear() {}
+
+type Suppressed struct{}
@@ -0,0 +1,3 @@
+package methodsaftertype
+
+type External struct{}
@@ -0,0 +1,42 @@
+package methodsgrouped
+
+type Counter struct {
+	n int
+}
+
+func NewCounter() *Counter { return &Counter{} }
+
+func (c *Counter) Inc() { c.n++ }
+
+func (c *Counter) Value() int { return c.n }
+
+type Timer struct {
+	ticks int
+}
+
+func (t *Timer) Tick() { t.ticks++ }
+
+func (c *Counter) Reset() { c.n = 0 } // want `method \(\*Counter\).Reset is separated from the previous method \(\*Counter\).Value of Counter by other declarations`
+
+func (t *Timer) Ticks() int { return t.ticks } // want `method \(\*Timer\).Ticks is separated from the previous method \(\*Timer\).Tick of Timer by other declarations`
+
+func (c *Counter) Dec() { c.n-- }
+
+func (t *Timer) Stop() { t.ticks = 0 }
+
+type Gauge struct {
+	v int
+}
+
+func (g *Gauge) Set(v int) { g.v = v }
+
+type Meter struct {
+	v int
+}
+
+func (m *Meter) Mark() { m.v++ }
+
+func (g *Gauge) Get() int { return g.v } //refdir:ignore
+
+//nolint:refdir // Kept next to Gauge on purpose.
+func (m *Meter) Count() int { return m.v }
@@ -0,0 +1,6 @@
+package methodsgrouped
+
+// Methods of a type in another file form their own group.
+func (c *Counter) Add(n int) { c.n += n }
+
+func (c *Counter) Sub(n int) { c.n -= n }
@@ -0,0 +1,15 @@
+package mindistance
+
+func NearRef() {
+	_ = nearType{}
+}
+
+type nearType struct{}
+
+func FarRef() {
+	_ = farType{} // want "type reference farType is before definition"
+}
+
+// A comment to move farType out of tolerance.
+
+type farType struct{}
@@ -0,0 +1,13 @@
+package external
+
+import "fmt"
+
+// Calls out of the package are not part of the recursion.
+func Ping(n int) { // want `skipping predeclared type int`
+	if n > 0 { // want `skipping var ident n with inner parent scope`
+		Pong(n - 1) // want `func reference Pong is mutually recursive with Ping` `skipping var ident n with inner parent scope`
+	}
+	fmt.Println(n) // want `pkg reference fmt ignored by options` `func reference Println is to definition in package fmt` `skipping var ident n with inner parent scope`
+}
+
+func Pong(n int) { Ping(n) } // want `skipping predeclared type int` `func reference Ping is mutually recursive with Pong` `skipping var ident n with inner parent scope`
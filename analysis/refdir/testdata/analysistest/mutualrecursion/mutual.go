@@ -0,0 +1,28 @@
+package mutualrecursion
+
+func MutualA(n int) int {
+	if n <= 0 {
+		return 0
+	}
+	return MutualB(n - 1)
+}
+
+func MutualB(n int) int {
+	if n <= 0 {
+		return 0
+	}
+	return MutualA(n - 1)
+}
+
+// Transitive cycles are allowed too: CycleA -> CycleB -> CycleC -> CycleA.
+func CycleA() { CycleB() }
+
+func CycleB() { CycleC() }
+
+func CycleC() { CycleA() }
+
+func NotRecursive() {}
+
+func CallsUp() {
+	NotRecursive() // want "func reference NotRecursive is after definition"
+}
@@ -0,0 +1,24 @@
+package nearmiss
+
+func Caller() {
+	helper() // want `func reference helper is before definition` `func reference helper is one declaration away from its definition, moving func unrelated would make them adjacent \(see -near-miss\)`
+}
+
+func unrelated() {}
+
+func helper() {}
+
+func Related() {
+	relatedHelper() // want `func reference relatedHelper is before definition`
+}
+
+// relatedHelper is also called by middle, so moving middle would not help.
+func middle() { relatedHelper() } // want `func reference relatedHelper is before definition`
+
+func relatedHelper() {}
+
+var limit = 10
+
+type spacer struct{}
+
+func Limited() int { return limit } // want `skipping predeclared type int` `var reference limit is after definition` `var reference limit is one declaration away from its definition, moving type spacer would make them adjacent \(see -near-miss\)`
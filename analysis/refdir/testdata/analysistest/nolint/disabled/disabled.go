@@ -0,0 +1,7 @@
+package disabled
+
+func Disabled() {
+	_ = disabledVar //nolint:refdir // want "var reference disabledVar is before definition"
+}
+
+var disabledVar int
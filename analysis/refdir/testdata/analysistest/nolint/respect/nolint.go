@@ -0,0 +1,29 @@
+package nolint
+
+func SameLine() {
+	_ = sameLineVar //nolint:refdir
+}
+
+func PrecedingLine() {
+	//nolint:refdir // Defined below on purpose.
+	_ = precedingLineVar
+}
+
+func Bare() {
+	_ = bareVar //nolint
+}
+
+func All() {
+	_ = allVar //nolint:gocritic,all
+}
+
+func OtherLinter() {
+	_ = otherLinterVar //nolint:gocritic // want "var reference otherLinterVar is before definition"
+}
+
+func NotNextLineOfTrailing() {
+	_ = 0           //nolint:refdir
+	_ = trailingVar // want "var reference trailingVar is before definition"
+}
+
+var sameLineVar, precedingLineVar, bareVar, allVar, otherLinterVar, trailingVar int
@@ -0,0 +1,13 @@
+package onlylines
+
+// The test reports errors on lines 6 to 7 only.
+func useLater() {
+	_ = Outside{}
+	_ = Inside{} // want "type reference Inside is before definition"
+	_ = Inside{} // want "type reference Inside is before definition"
+	_ = Outside{}
+}
+
+type Inside struct{}
+
+type Outside struct{}
@@ -0,0 +1,10 @@
+package pkgnames
+
+import (
+	"fmt"
+	str "strings"
+)
+
+func greet(name string) string {
+	return fmt.Sprint("hello ", str.TrimSpace(name)) // want "pkg reference fmt is after definition" "pkg reference str is after definition"
+}
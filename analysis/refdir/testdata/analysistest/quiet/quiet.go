@@ -0,0 +1,8 @@
+package quiet
+
+func Quiet() {
+	_ = quietVar
+	_ = otherQuietVar
+}
+
+var quietVar, otherQuietVar int
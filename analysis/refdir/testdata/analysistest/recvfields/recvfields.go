@@ -0,0 +1,25 @@
+package recvfields
+
+// Fields of the receiver type are ordered against the receiver type declaration.
+func (t *Thermo) Read() int { // want "recvtype reference Thermo is before definition"
+	return t.temperature // want "recvtype reference temperature is before definition"
+}
+
+type inner struct {
+	depth int
+}
+
+type Thermo struct {
+	temperature int
+	inner
+}
+
+// Promoted fields belong to the embedded struct, not to the receiver type.
+func (t *Thermo) Depth() int {
+	return t.depth + t.temperature
+}
+
+// Fields of other values of the type are receiver fields too.
+func (t *Thermo) Warmer(other Thermo) bool {
+	return other.temperature > t.temperature
+}
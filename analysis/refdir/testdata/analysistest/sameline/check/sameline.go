@@ -0,0 +1,13 @@
+package check
+
+// Declarations share lines on purpose, so this file is not gofmt-formatted.
+
+func Caller() { callee() }; func callee() {}
+
+func Helper() {}; func User() { Helper() } // want "func reference Helper is after definition"
+
+var early = late; var late = 1 // want "var reference late is before definition"
+
+var first, second = 1, first
+
+type Node struct{ next *Node }
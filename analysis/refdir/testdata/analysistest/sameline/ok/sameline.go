@@ -0,0 +1,13 @@
+package ok
+
+// Declarations share lines on purpose, so this file is not gofmt-formatted.
+
+func Caller() { callee() }; func callee() {}
+
+func Helper() {}; func User() { Helper() }
+
+var early = late; var late = 1
+
+var first, second = 1, first
+
+type Node struct{ next *Node }
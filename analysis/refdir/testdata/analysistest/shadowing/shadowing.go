@@ -0,0 +1,18 @@
+package shadowing
+
+var count int // want `skipping predeclared type int`
+
+const limit = 3
+
+type item struct{}
+
+var unused int // want `skipping predeclared type int`
+
+func f(count int) int { // want `local var count shadows package-scope var count \(.*/shadowing.go:3:5\), whose references are ordered \(see -warn-shadowing\)` `skipping predeclared type int` `skipping predeclared type int`
+	limit := count        // want `local var limit shadows package-scope const limit \(.*/shadowing.go:5:7\), whose references are ordered \(see -warn-shadowing\)` `skipping var ident count with inner parent scope`
+	type item int         // want `local type item shadows package-scope type item \(.*/shadowing.go:7:6\), whose references are ordered \(see -warn-shadowing\)` `skipping predeclared type int`
+	unused := item(limit) // want `skipping type ident item with inner parent scope` `skipping var ident limit with inner parent scope`
+	return int(unused)    // want `skipping predeclared type int` `skipping var ident unused with inner parent scope`
+}
+
+func g() { count = limit; _ = item{} } // want `var reference count is after definition` `const reference limit is after definition` `type reference item is after definition`
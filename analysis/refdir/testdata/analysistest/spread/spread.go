@@ -0,0 +1,24 @@
+package spread
+
+type T struct{ n int } // want `skipping predeclared type int`
+
+func (T) String() string { return "" } // want `recvtype reference T is after definition` `skipping predeclared type string`
+
+type Stringer interface{ String() string } // want `skipping predeclared type string`
+
+const limit = 3
+
+var count int // want `skipping predeclared type int`
+
+func God(s Stringer) string { // want `func God references 6 package-scope declarations, more than 2 \(see -spread-threshold\): Stringer, T, T.String, count, helper, limit` `type reference Stringer is after definition` `skipping predeclared type string`
+	t := T{n: limit} // want `type reference T is after definition` `skipping field name n in struct literal \(see -field-dir\)` `const reference limit is after definition`
+	count++          // want `var reference count is after definition`
+	_ = helper(t.n)  // want `func reference helper is before definition` `skipping var ident t with inner parent scope` `field reference n ignored by options`
+	_ = s.String()   // want `skipping var ident s with inner parent scope` `ifacetype reference String is after definition`
+	_ = God
+	return t.String() // want `skipping var ident t with inner parent scope` `func reference String is after definition`
+}
+
+func Small() int { return helper(limit) } // want `skipping predeclared type int` `func reference helper is before definition` `const reference limit is after definition`
+
+func helper(n int) int { return n } // want `skipping predeclared type int` `skipping predeclared type int` `skipping var ident n with inner parent scope`
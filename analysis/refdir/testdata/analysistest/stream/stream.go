@@ -0,0 +1,14 @@
+package stream
+
+// With -stream, the reference error is reported before the method one, although it comes
+// later in the file.
+
+func (*T) method() {} // want "method \\(\\*T\\).method is declared before its receiver type T"
+
+type T struct{}
+
+func helper() {}
+
+func caller() {
+	helper() // want "func reference helper is after definition"
+}
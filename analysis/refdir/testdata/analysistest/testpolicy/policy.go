@@ -0,0 +1,7 @@
+package testpolicy
+
+func useLater() {
+	_ = later{} // want "type reference later is before definition"
+}
+
+type later struct{}
@@ -0,0 +1,10 @@
+package testpolicy
+
+import "testing"
+
+// Test files keep their fixtures at the bottom.
+func TestFixture(t *testing.T) {
+	_ = fixture{}
+}
+
+type fixture struct{}
@@ -0,0 +1,18 @@
+package exported
+
+type used struct{}
+
+type unused struct{} // want `type unused is never referenced in the package \(see -report-unreferenced\)`
+
+type Exported struct{} // want `type Exported is never referenced in the package \(see -report-unreferenced\)`
+
+const limit = 3
+
+var count = limit // want `var count is never referenced in the package \(see -report-unreferenced\)` `const reference limit is after definition`
+
+func helper(used) {} // want `type reference used is after definition`
+
+// A declaration referenced only by itself is referenced.
+func orphan() { orphan() }
+
+func init() { helper(used{}) } // want `func reference helper is after definition` `type reference used is after definition`
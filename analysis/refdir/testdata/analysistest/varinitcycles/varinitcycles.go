@@ -0,0 +1,36 @@
+package varinitcycles
+
+type greeter interface {
+	greet() string
+	part() string
+	wave() string
+}
+
+type english struct{}
+
+var speaker greeter = english{}
+
+// greeting is read by english.greet while it is being initialized.
+var greeting = "hello " + speaker.greet() // want "initialization cycle of vars greeting"
+
+var (
+	first  = 1
+	second = first + 1
+)
+
+func (english) greet() string {
+	return greeting
+}
+
+var farewell = "bye " + speaker.part() //refdir:ignore
+
+//nolint:refdir // Read back by english.wave on purpose.
+var wave = "wave " + speaker.wave()
+
+func (english) part() string {
+	return farewell
+}
+
+func (english) wave() string {
+	return wave
+}
@@ -0,0 +1,16 @@
+package visibility
+
+func Exported() {
+	_ = LaterExportedType{} // want "type reference LaterExportedType is before definition"
+	_ = laterUnexportedType{}
+	unexported()
+}
+
+func unexported() {
+	Exported() // want "func reference Exported is after definition"
+	_ = laterUnexportedType{}
+}
+
+type LaterExportedType struct{}
+
+type laterUnexportedType struct{}
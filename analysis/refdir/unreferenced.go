@@ -0,0 +1,52 @@
+package refdir
+
+import (
+	"cmp"
+	"go/ast"
+	"go/types"
+	"slices"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// unreferencedDecls returns the package-scope declarations of pass missing from referenced,
+// sorted by position. The main func of a main package, exported declarations unless
+// includeExported, and declarations in generated files unless includeGenerated are left out.
+func unreferencedDecls(pass *analysis.Pass, referenced map[types.Object]bool, includeGenerated, includeExported bool) []types.Object {
+	generated := make(map[string]bool)
+	for _, file := range pass.Files {
+		if ast.IsGenerated(file) && !includeGenerated {
+			generated[pass.Fset.File(file.Pos()).Name()] = true
+		}
+	}
+
+	var objs []types.Object
+	scope := pass.Pkg.Scope()
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+		tf := pass.Fset.File(obj.Pos())
+		switch {
+		case referenced[obj], tf == nil, generated[tf.Name()]:
+		case obj.Exported() && !includeExported:
+		case pass.Pkg.Name() == "main" && name == "main":
+		default:
+			objs = append(objs, obj)
+		}
+	}
+	slices.SortFunc(objs, func(a, b types.Object) int { return cmp.Compare(a.Pos(), b.Pos()) })
+	return objs
+}
+
+// declKind returns the kind of references to the package-scope declaration obj.
+func declKind(obj types.Object) RefKind {
+	switch obj.(type) {
+	case *types.Func:
+		return Func
+	case *types.TypeName:
+		return Type
+	case *types.Const:
+		return Const
+	default:
+		return Var
+	}
+}
@@ -0,0 +1,10 @@
+// Command refdir-facts reports, for each package, the refdir ordering issues of the package
+// and of its dependencies in the same module, aggregated from per-package facts.
+package main
+
+import (
+	"github.com/ppipada/refdir/analysis/refdir"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() { singlechecker.Main(refdir.FactsAnalyzer) }
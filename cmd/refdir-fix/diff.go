@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines around each hunk.
+const diffContext = 3
+
+// edit is a line of an edit script: kept (' '), deleted ('-') or inserted ('+').
+type edit struct {
+	op   byte
+	line string
+}
+
+// unifiedDiff returns a unified diff turning before into after, as printed by gofmt -d, or
+// "" when they are equal.
+func unifiedDiff(name string, before, after []byte) string {
+	edits := diffLines(splitLines(string(before)), splitLines(string(after)))
+
+	// Lines of before and after preceding each edit.
+	aLine, bLine := make([]int, len(edits)+1), make([]int, len(edits)+1)
+	for i, e := range edits {
+		aLine[i+1], bLine[i+1] = aLine[i], bLine[i]
+		if e.op != '+' {
+			aLine[i+1]++
+		}
+		if e.op != '-' {
+			bLine[i+1]++
+		}
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(edits); {
+		if edits[i].op == ' ' {
+			i++
+			continue
+		}
+		// Extend the hunk over changes separated by at most twice the context.
+		end := i + 1
+		for j := end; j < len(edits) && j-end < 2*diffContext; j++ {
+			if edits[j].op != ' ' {
+				end = j + 1
+			}
+		}
+		start, stop := max(i-diffContext, 0), min(end+diffContext, len(edits))
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s.orig\n+++ %s\n", name, name)
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n",
+			hunkRange(aLine[start], aLine[stop]-aLine[start]),
+			hunkRange(bLine[start], bLine[stop]-bLine[start]))
+		for _, e := range edits[start:stop] {
+			out.WriteByte(e.op)
+			out.WriteString(e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = stop
+	}
+	return out.String()
+}
+
+// splitLines splits s after each newline.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns a shortest edit script turning a into b, using Myers' algorithm.
+func diffLines(a, b []string) []edit {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	// The trace keeps, for each step d, only the diagonals -d-1 to d+1 that step reads, so
+	// it grows with the square of the number of edits rather than with the input size.
+	var trace [][]int
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, slices.Clone(v[offset-d-1:offset+d+2]))
+		for k := -d; k <= d; k += 2 {
+			x := v[offset+k-1] + 1
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrack walks the furthest reaching paths recorded by diffLines back from the end of
+// both inputs, collecting the edits on the way. The trace of step d starts at diagonal -d-1.
+func backtrack(trace [][]int, a, b []string) []edit {
+	var edits []edit
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		v, offset := trace[d], d+1
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			edits = append(edits, edit{' ', a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, edit{'+', b[y-1]})
+			} else {
+				edits = append(edits, edit{'-', a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+	slices.Reverse(edits)
+	return edits
+}
+
+// hunkRange formats the range of lines after line start, as in "4,7", or "4" for one line.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
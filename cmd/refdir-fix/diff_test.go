@@ -0,0 +1,75 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	after := "a\nx\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"
+	want := `--- p.go.orig
++++ p.go
+@@ -1,5 +1,5 @@
+ a
+-b
++x
+ c
+ d
+ e
+@@ -10,4 +10,3 @@
+ j
+ k
+ l
+-m
+`
+	if got := unifiedDiff("p.go", []byte(before), []byte(after)); got != want {
+		t.Errorf("Unexpected diff:\n got %q\nwant %q", got, want)
+	}
+	if got := unifiedDiff("p.go", []byte(before), []byte(before)); got != "" {
+		t.Errorf("Expected no diff for equal inputs, got %q", got)
+	}
+}
+
+func TestUnifiedDiffMissingNewline(t *testing.T) {
+	want := "--- p.go.orig\n+++ p.go\n@@ -1 +1 @@\n-a\n\\ No newline at end of file\n+a\n"
+	if got := unifiedDiff("p.go", []byte("a"), []byte("a\n")); got != want {
+		t.Errorf("Unexpected diff:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	for _, tc := range []struct {
+		a, b  string
+		edits int
+	}{
+		{a: "", b: "", edits: 0},
+		{a: "", b: "abc", edits: 3},
+		{a: "abc", b: "", edits: 3},
+		{a: "abcabba", b: "cbabac", edits: 5},
+		{a: "abcdefgh", b: "efghabcd", edits: 8},
+		{a: "xaxbxcx", b: "abc", edits: 4},
+	} {
+		a, b := strings.Split(tc.a, ""), strings.Split(tc.b, "")
+		var gotA, gotB []string
+		changes := 0
+		for _, e := range diffLines(a, b) {
+			if e.op != '+' {
+				gotA = append(gotA, e.line)
+			}
+			if e.op != '-' {
+				gotB = append(gotB, e.line)
+			}
+			if e.op != ' ' {
+				changes++
+			}
+		}
+		if !slices.Equal(gotA, a) || !slices.Equal(gotB, b) {
+			t.Errorf("%q -> %q: edits turn %q into %q", tc.a, tc.b, gotA, gotB)
+		}
+		if changes != tc.edits {
+			t.Errorf("%q -> %q: %d edits, want %d", tc.a, tc.b, changes, tc.edits)
+		}
+	}
+}
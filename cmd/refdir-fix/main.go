@@ -0,0 +1,130 @@
+// Command refdir-fix reorders the top-level declarations of Go files so that references
+// between them follow the configured directions.
+//
+// It lists the files that need reordering, rewrites them with -w, or prints the changes as a
+// unified diff with -diff. Files for which no
+// valid ordering exists, such as files with mutually recursive functions under the
+// default directions, are reported with the conflicting declarations and left unchanged.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"maps"
+	"os"
+
+	"github.com/ppipada/refdir/analysis/refdir"
+	"golang.org/x/tools/go/packages"
+)
+
+// mode selects what is done with reordered files.
+type mode int
+
+const (
+	modeList mode = iota
+	modeWrite
+	modeDiff
+)
+
+func main() {
+	order := maps.Clone(refdir.RefOrder)
+	write := flag.Bool("w", false, "write the reordered files instead of listing them")
+	diff := flag.Bool("diff", false, "print a unified diff of the reordered files instead of listing them")
+	tests := flag.Bool("test", true, "also reorder test files")
+	for _, kind := range refdir.RefKinds {
+		flag.Func(string(kind)+"-dir", fmt.Sprintf("direction of %s references (default %s)", kind, order[kind]),
+			func(s string) error {
+				var dir refdir.Direction
+				if err := dir.Set(s); err != nil {
+					return err
+				}
+				order[kind] = dir
+				return nil
+			})
+	}
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: refdir-fix [flags] packages...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	m := modeList
+	switch {
+	case *write && *diff:
+		fmt.Fprintln(os.Stderr, "refdir-fix: -w and -diff are mutually exclusive")
+		os.Exit(1)
+	case *write:
+		m = modeWrite
+	case *diff:
+		m = modeDiff
+	}
+
+	os.Exit(run(flag.Args(), order, *tests, m))
+}
+
+// run reorders the files of the packages matching patterns and returns the exit code.
+func run(patterns []string, order map[refdir.RefKind]refdir.Direction, tests bool, m mode) int {
+	cfg := &packages.Config{
+		Mode:  packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo,
+		Tests: tests,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		return 1
+	}
+
+	exit := 0
+	// Test variants of a package contain its files again.
+	done := make(map[string]bool)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			name := pkg.Fset.File(file.Pos()).Name()
+			if done[name] || ast.IsGenerated(file) {
+				continue
+			}
+			done[name] = true
+			if err := reorderFile(pkg, file, name, order, m); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+				exit = 1
+			}
+		}
+	}
+	return exit
+}
+
+// reorderFile reorders a single file, printing its name or diff if it changes.
+func reorderFile(pkg *packages.Package, file *ast.File, name string, order map[refdir.RefKind]refdir.Direction, m mode) error {
+	src, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	out, err := refdir.Reorder(pkg.Fset, file, pkg.TypesInfo, src, order)
+	if err != nil || bytes.Equal(src, out) {
+		return err
+	}
+	switch m {
+	case modeList:
+		fmt.Println(name)
+		return nil
+	case modeDiff:
+		// Reorder formats its output with go/format, so the diff is stable under gofmt.
+		fmt.Print(unifiedDiff(name, src, out))
+		return nil
+	case modeWrite:
+	}
+	fmt.Println(name)
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, out, info.Mode().Perm())
+}
@@ -0,0 +1,122 @@
+// Command refdir runs the refdir analyzer with an exit code contract suitable for CI:
+//
+//	0  no ordering errors
+//	1  the analysis failed to run, e.g. a package did not load
+//	N  ordering errors were found, where N is set by -error-exitcode (default 3)
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+
+	"github.com/ppipada/refdir/analysis/refdir"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
+)
+
+func main() {
+	counter := &refdir.ErrorCounter{}
+	opts := refdir.DefaultOptions()
+	opts.ErrorCounter = counter
+	analyzer := refdir.NewWithOptions(opts)
+
+	errorExitCode := flag.Int("error-exitcode", 3, "exit code used when ordering errors are found")
+	tests := flag.Bool("test", true, "also check test files")
+	cpuProfile := flag.String("cpuprofile", "", "write a CPU profile of the run to this file")
+	memProfile := flag.String("memprofile", "", "write a memory profile to this file at the end of the run")
+	analyzer.Flags.VisitAll(func(f *flag.Flag) { flag.Var(f.Value, f.Name, f.Usage) })
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: refdir [flags] packages...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	os.Exit(profiled(*cpuProfile, *memProfile, func() int {
+		return run(analyzer, counter, flag.Args(), *tests, *errorExitCode)
+	}))
+}
+
+// profiled calls run and returns its exit code, writing a CPU profile of it to cpuProfile
+// and a heap profile after it to memProfile when they are set. Packages are analyzed
+// concurrently and the CPU profiler is process-wide, so profiles cover the whole run; to
+// profile a slow package, run refdir on that package alone.
+func profiled(cpuProfile, memProfile string, run func() int) int {
+	if cpuProfile != "" {
+		f, err := os.Create(cpuProfile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	code := run()
+
+	if memProfile != "" {
+		f, err := os.Create(memProfile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		// Collect garbage first, so the profile shows live memory.
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	return code
+}
+
+// run checks the packages matching patterns and returns the process exit code.
+func run(analyzer *analysis.Analyzer, counter *refdir.ErrorCounter, patterns []string, tests bool, errorExitCode int) int {
+	cfg := &packages.Config{Mode: packages.LoadAllSyntax, Tests: tests}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		return 1
+	}
+
+	graph, err := checker.Analyze([]*analysis.Analyzer{analyzer}, pkgs, nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	failed := false
+	for act := range graph.All() {
+		if act.Err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", act, act.Err)
+			failed = true
+		}
+	}
+	if err := graph.PrintText(os.Stderr, -1); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	switch {
+	case failed:
+		return 1
+	case counter.Count() > 0:
+		return errorExitCode
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ppipada/refdir/analysis/refdir"
+)
+
+func TestRunExitCodes(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		want    int
+	}{
+		{pattern: "./testdata/clean", want: 0},
+		{pattern: "./testdata/broken", want: 1},
+		{pattern: "./testdata/errors", want: 5},
+	} {
+		t.Run(tc.pattern, func(t *testing.T) {
+			counter := &refdir.ErrorCounter{}
+			opts := refdir.DefaultOptions()
+			opts.Colorize = false
+			opts.ErrorCounter = counter
+			if got := run(refdir.NewWithOptions(opts), counter, []string{tc.pattern}, false, 5); got != tc.want {
+				t.Errorf("Expected exit code %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
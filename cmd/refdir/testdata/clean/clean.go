@@ -0,0 +1,5 @@
+package clean
+
+func Caller() { callee() }
+
+func callee() {}
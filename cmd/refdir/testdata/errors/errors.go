@@ -0,0 +1,5 @@
+package errors
+
+func Caller() { _ = later }
+
+var later = 1
@@ -2,7 +2,6 @@ package golangcilint
 
 import (
 	"fmt"
-	"slices"
 
 	"github.com/golangci/plugin-module-register/register"
 	"github.com/ppipada/refdir/analysis/refdir"
@@ -21,34 +20,33 @@ func New(rawSettings any) (register.LinterPlugin, error) {
 		return nil, err
 	}
 
-	parsedOrder := map[refdir.RefKind]refdir.Direction{}
+	opts := refdir.DefaultOptions()
+	opts.Colorize = false
+	// The golangci-lint settings are the only source of configuration.
+	opts.Config = false
+	// golangci-lint applies //nolint directives itself.
+	opts.RespectNolint = false
 	for key, value := range settings.Directions {
-		if !slices.Contains(refdir.RefKinds, refdir.RefKind(key)) {
-			return nil, fmt.Errorf("invalid refdir settings key %q", key)
+		var kind refdir.RefKind
+		if err := kind.Set(key); err != nil {
+			return nil, fmt.Errorf("refdir directions: %w", err)
 		}
-		if !slices.Contains(refdir.Directions, refdir.Direction(value)) {
-			return nil, fmt.Errorf("invalid refdir direction %q for settings key %q", value, key)
+		var dir refdir.Direction
+		if err := dir.Set(value); err != nil {
+			return nil, fmt.Errorf("refdir directions[%s]: %w", kind, err)
 		}
-		parsedOrder[refdir.RefKind(key)] = refdir.Direction(value)
+		opts.RefOrder[kind] = dir
 	}
 
-	return &Plugin{settings: parsedOrder}, nil
+	return &Plugin{opts: opts}, nil
 }
 
 type Plugin struct {
-	settings map[refdir.RefKind]refdir.Direction
+	opts refdir.Options
 }
 
 func (p *Plugin) BuildAnalyzers() ([]*analysis.Analyzer, error) {
-	for kind, dir := range p.settings {
-		if err := refdir.Analyzer.Flags.Set(fmt.Sprintf("%s-dir", kind), fmt.Sprint(dir)); err != nil {
-			return nil, err
-		}
-	}
-	if err := refdir.Analyzer.Flags.Set("color", "false"); err != nil {
-		return nil, fmt.Errorf("failed to disable color setting: %w", err)
-	}
-	return []*analysis.Analyzer{refdir.Analyzer}, nil
+	return []*analysis.Analyzer{refdir.NewWithOptions(p.opts)}, nil
 }
 
 func (p *Plugin) GetLoadMode() string {
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -70,6 +71,21 @@ func TestPlugin(t *testing.T) {
 	}
 }
 
+func TestNewInvalidSettings(t *testing.T) {
+	for _, tc := range []struct {
+		directions map[string]any
+		want       string
+	}{
+		{map[string]any{"fn": "down"}, `refdir directions: invalid kind "fn"`},
+		{map[string]any{"func": "sideways"}, `refdir directions[func]: invalid direction "sideways"`},
+	} {
+		_, err := New(map[string]any{"directions": tc.directions})
+		if err == nil || !strings.HasPrefix(err.Error(), tc.want) {
+			t.Errorf("New(%v) error = %v, want prefix %q", tc.directions, err, tc.want)
+		}
+	}
+}
+
 func exitCode(err error) int {
 	var exitErr *exec.ExitError
 	if !errors.As(err, &exitErr) {